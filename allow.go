@@ -0,0 +1,141 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+var errInvalidToken = errors.New("invalid token")
+
+// CORS holds the CORS response headers, see [WithCORSInto].
+type CORS struct {
+	// AllowOrigin is the value of the Access-Control-Allow-Origin header.
+	AllowOrigin string
+
+	// AllowMethods are the methods of the Access-Control-Allow-Methods header.
+	AllowMethods []HTTPMethod
+
+	// AllowHeaders are the canonical keys of
+	// the Access-Control-Allow-Headers header.
+	AllowHeaders []string
+
+	// AllowCredentials reports whether the Access-Control-Allow-Credentials
+	// header is "true".
+	AllowCredentials bool
+
+	// ExposeHeaders are the canonical keys of
+	// the Access-Control-Expose-Headers header.
+	ExposeHeaders []string
+
+	// MaxAge is the value of the Access-Control-Max-Age header.
+	MaxAge time.Duration
+}
+
+func parseCORS(header http.Header) (CORS, error) {
+	methods, err := parseMethodList(header, HeaderAccessControlAllowMethods)
+	if err != nil {
+		return CORS{}, err
+	}
+
+	allowHeaders, err := parseHeaderKeyList(header, HeaderAccessControlAllowHeaders)
+	if err != nil {
+		return CORS{}, err
+	}
+
+	exposeHeaders, err := parseHeaderKeyList(header, HeaderAccessControlExposeHeaders)
+	if err != nil {
+		return CORS{}, err
+	}
+
+	var maxAge time.Duration
+	if value := header.Get(string(HeaderAccessControlMaxAge)); value != "" {
+		seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return CORS{}, fmt.Errorf("header %s: %w", HeaderAccessControlMaxAge, err)
+		}
+		maxAge = time.Duration(seconds) * time.Second
+	}
+
+	return CORS{
+		AllowOrigin:      strings.TrimSpace(header.Get(string(HeaderAccessControlAllowOrigin))),
+		AllowMethods:     methods,
+		AllowHeaders:     allowHeaders,
+		AllowCredentials: header.Get(string(HeaderAccessControlAllowCredentials)) == "true",
+		ExposeHeaders:    exposeHeaders,
+		MaxAge:           maxAge,
+	}, nil
+}
+
+// parseMethodList parses the comma-separated methods of the given header.
+// The methods are uppercased, since some servers send them in lowercase.
+func parseMethodList(header http.Header, key HeaderKey) ([]HTTPMethod, error) {
+	tokens, err := parseTokenList(header, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var methods []HTTPMethod
+	for _, token := range tokens {
+		methods = appendUnique(methods, HTTPMethod(strings.ToUpper(token)))
+	}
+
+	return methods, nil
+}
+
+// parseHeaderKeyList parses the comma-separated header keys of the given
+// header, canonicalizing them.
+func parseHeaderKeyList(header http.Header, key HeaderKey) ([]string, error) {
+	tokens, err := parseTokenList(header, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, token := range tokens {
+		keys = appendUnique(keys, textproto.CanonicalMIMEHeaderKey(token))
+	}
+
+	return keys, nil
+}
+
+// parseTokenList parses the comma-separated tokens of all values
+// of the given header, skipping empty elements.
+func parseTokenList(header http.Header, key HeaderKey) ([]string, error) {
+	var tokens []string
+	for _, value := range header.Values(string(key)) {
+		for _, token := range strings.Split(value, ",") {
+			token = strings.TrimSpace(token)
+			if token == "" {
+				continue
+			}
+
+			if !httpguts.ValidHeaderFieldName(token) {
+				return nil, fmt.Errorf("header %s: %q: %w", key, token, errInvalidToken)
+			}
+
+			tokens = append(tokens, token)
+		}
+	}
+
+	return tokens, nil
+}
+
+func appendUnique[T comparable](s []T, v T) []T {
+	for _, e := range s {
+		if e == v {
+			return s
+		}
+	}
+
+	return append(s, v)
+}
@@ -0,0 +1,125 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseMethodList(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		values  []string
+		want    []HTTPMethod
+		wantErr bool
+	}{
+		{
+			name: "missing",
+		},
+		{
+			name:   "empty",
+			values: []string{""},
+		},
+		{
+			name:   "spaces and empty elements",
+			values: []string{" GET ,, HEAD,", "OPTIONS"},
+			want:   []HTTPMethod{GET, "HEAD", OPTIONS},
+		},
+		{
+			name:   "lowercase and duplicates",
+			values: []string{"get, Post, GET"},
+			want:   []HTTPMethod{GET, POST},
+		},
+		{
+			name:    "invalid token",
+			values:  []string{"GET, P@ST"},
+			wantErr: true,
+		},
+		{
+			name:    "space inside token",
+			values:  []string{"GET POST"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			header := http.Header{string(HeaderAllow): tt.values}
+
+			got, err := parseMethodList(header, HeaderAllow)
+			if tt.wantErr {
+				require.ErrorIs(t, err, errInvalidToken)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_WithAllowInto(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(string(HeaderAllow), "get, head, options")
+		w.Header().Set(string(HeaderAccessControlAllowOrigin), "https://example.com")
+		w.Header().Set(string(HeaderAccessControlAllowMethods), "GET, POST")
+		w.Header().Set(string(HeaderAccessControlAllowHeaders), "content-type, x-request-id")
+		w.Header().Set(string(HeaderAccessControlAllowCredentials), "true")
+		w.Header().Set(string(HeaderAccessControlExposeHeaders), "etag")
+		w.Header().Set(string(HeaderAccessControlMaxAge), "600")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var methods []HTTPMethod
+	var cors CORS
+
+	err := Options(server.URL,
+		WithAllowInto(&methods),
+		WithCORSInto(&cors),
+		WithOK(http.StatusNoContent, http.StatusOK).ToDiscard(),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []HTTPMethod{GET, "HEAD", OPTIONS}, methods)
+	assert.Equal(t, CORS{
+		AllowOrigin:      "https://example.com",
+		AllowMethods:     []HTTPMethod{GET, POST},
+		AllowHeaders:     []string{"Content-Type", "X-Request-Id"},
+		AllowCredentials: true,
+		ExposeHeaders:    []string{"Etag"},
+		MaxAge:           10 * time.Minute,
+	}, cors)
+
+	err = Options(server.URL, WithAllowInto(nil))
+	require.Error(t, err)
+
+	err = Options(server.URL, WithCORSInto(nil))
+	require.Error(t, err)
+}
+
+func Test_parseCORS(t *testing.T) {
+	t.Parallel()
+
+	cors, err := parseCORS(http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, CORS{}, cors)
+
+	_, err = parseCORS(http.Header{string(HeaderAccessControlMaxAge): {"ten"}})
+	require.Error(t, err)
+
+	_, err = parseCORS(http.Header{string(HeaderAccessControlAllowHeaders): {"X-A, (x)"}})
+	require.ErrorIs(t, err, errInvalidToken)
+}
@@ -0,0 +1,99 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrPanic is returned by [DoAsync] and [DoExpectAsync] when the request
+// panics, e.g., in a handler, instead of crashing the program.
+var ErrPanic = errors.New("request panicked")
+
+// AsyncResult is the result of [DoExpectAsync].
+type AsyncResult[T any] struct {
+	Value T
+	Err   error
+}
+
+// DoAsync sends an HTTP request given [HTTPMethod], URL, and optional
+// parameters in a new goroutine, see [Do]. The returned channel receives
+// exactly one error, nil on success, and then is closed. The channel is
+// buffered, so the goroutine does not leak if the result is never received.
+//
+// Use [WithContext] to cancel the request, and [WithAsyncPool] to bound
+// the number of requests sent concurrently. A panic, e.g., in a handler,
+// is converted to the [ErrPanic] error.
+func DoAsync(httpMethod HTTPMethod, url string, opts ...Option) <-chan error {
+	result := make(chan error, 1)
+
+	go func() {
+		defer close(result)
+		result <- runAsync(func() error {
+			return Do(httpMethod, url, withAsync(opts)...)
+		})
+	}()
+
+	return result
+}
+
+// DoExpectAsync is [DoExpect] sent in a new goroutine like [DoAsync].
+// The returned channel receives exactly one result and then is closed.
+func DoExpectAsync[T any, E error](
+	httpMethod HTTPMethod,
+	url string,
+	errorStatuses []int,
+	opts ...Option,
+) <-chan AsyncResult[T] {
+	result := make(chan AsyncResult[T], 1)
+
+	go func() {
+		defer close(result)
+
+		var value T
+		err := runAsync(func() (err error) {
+			value, err = DoExpect[T, E](httpMethod, url, errorStatuses, withAsync(opts)...)
+			return err
+		})
+		result <- AsyncResult[T]{Value: value, Err: err}
+	}()
+
+	return result
+}
+
+// WithAsyncPool bounds the number of requests sent concurrently by [DoAsync]
+// and [DoExpectAsync] by the given [Semaphore] shared across them. The requests
+// are queued until a request in flight is done, respecting the context
+// cancellation. It has no effect on [Do].
+func WithAsyncPool(pool *Semaphore) Option {
+	return func(params *doParams) error {
+		params.asyncPool = pool
+		return nil
+	}
+}
+
+// withAsync returns a copy of the given options marking the request as sent
+// by [DoAsync] or [DoExpectAsync].
+func withAsync(opts []Option) []Option {
+	all := make([]Option, 0, len(opts)+1)
+	all = append(all, opts...)
+	all = append(all, func(params *doParams) error {
+		params.async = true
+		return nil
+	})
+
+	return all
+}
+
+// runAsync calls the given function converting its panic to the error.
+func runAsync(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrPanic, r)
+		}
+	}()
+
+	return fn()
+}
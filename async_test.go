@@ -0,0 +1,109 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DoAsync(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	result := DoAsync(GET, server.URL, WithExpectStatus(http.StatusOK))
+	require.NoError(t, <-result)
+	_, ok := <-result
+	assert.False(t, ok)
+
+	result = DoAsync(GET, server.URL,
+		WithHandlerBeforeResponse(func(*http.Request) error { panic("boom") }),
+	)
+	err := <-result
+	require.ErrorIs(t, err, ErrPanic)
+	assert.Contains(t, err.Error(), "boom")
+	_, ok = <-result
+	assert.False(t, ok)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = <-DoAsync(GET, server.URL, WithContext(ctx), WithAsyncPool(NewSemaphore(1)))
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_WithAsyncPool(t *testing.T) {
+	t.Parallel()
+
+	const n = 3
+
+	var inFlight, maxInFlight atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			prev := maxInFlight.Load()
+			if current <= prev || maxInFlight.CompareAndSwap(prev, current) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	pool := NewSemaphore(n)
+	results := make([]<-chan error, 0, 10)
+	for i := 0; i < cap(results); i++ {
+		results = append(results, DoAsync(GET, server.URL,
+			WithAsyncPool(pool),
+			WithExpectStatus(http.StatusOK),
+		))
+	}
+
+	var wg sync.WaitGroup
+	for _, result := range results {
+		wg.Add(1)
+		go func(result <-chan error) {
+			defer wg.Done()
+			assert.NoError(t, <-result)
+		}(result)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(n))
+}
+
+func Test_DoExpectAsync(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]int{"id": 42})
+	}))
+	defer server.Close()
+
+	type result struct {
+		ID int `json:"id"`
+	}
+
+	results := DoExpectAsync[result, *apiError](GET, server.URL, nil)
+	got := <-results
+	require.NoError(t, got.Err)
+	assert.Equal(t, 42, got.Value.ID)
+	_, ok := <-results
+	assert.False(t, ok)
+}
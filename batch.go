@@ -0,0 +1,94 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchRequest is a request sent by [DoAll].
+type BatchRequest struct {
+	Method HTTPMethod
+	URL    string
+	Opts   []Option
+}
+
+// BatchResult is the result of [BatchRequest] sent by [DoAll].
+type BatchResult struct {
+	// Index is the index of the request in the batch.
+	Index int
+
+	// Err is the error returned by [Do], or the context error
+	// if the request has not been sent, because the context is done.
+	Err error
+
+	// Value is the result set by [OKStatuses.To] and alike, e.g., the pointer
+	// given to [OKStatuses.ToJSON], if the response is handled by it,
+	// otherwise nil.
+	Value any
+}
+
+// DoAll sends the given requests with at most the given number of them
+// in flight, see [Do], and returns their results in the order of the requests
+// regardless of the order of completion. If parallelism is less than 1,
+// the requests are sent one by one.
+//
+// The given context is used by the requests unless they set their own one
+// by [WithContext]. Once the context is done, no more requests are sent,
+// and the results of the unsent ones carry the context error, while
+// the requests in flight finish or abort according to their contexts.
+func DoAll(ctx context.Context, reqs []BatchRequest, parallelism int) []BatchResult {
+	results := make([]BatchResult, len(reqs))
+	for i := range results {
+		results[i].Index = i
+	}
+
+	indices := make(chan int)
+	go func() {
+		defer close(indices)
+		for i := range reqs {
+			select {
+			case indices <- i:
+			case <-ctx.Done():
+				for ; i < len(reqs); i++ {
+					results[i].Err = ctx.Err()
+				}
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for n := max(parallelism, 1); n > 0; n-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i].Value, results[i].Err = doBatchRequest(ctx, reqs[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+func doBatchRequest(ctx context.Context, req BatchRequest) (any, error) {
+	var value any
+
+	opts := make([]Option, 0, len(req.Opts)+2)
+	opts = append(opts, WithContext(ctx))
+	opts = append(opts, req.Opts...)
+	opts = append(opts, func(params *doParams) error {
+		params.handler.okResult = &value
+		return nil
+	})
+
+	if err := Do(req.Method, req.URL, opts...); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
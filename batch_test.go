@@ -0,0 +1,93 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DoAll(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// The later requests complete first.
+		time.Sleep(time.Duration(10-id) * time.Millisecond)
+		_ = json.NewEncoder(w).Encode(map[string]int{"id": id})
+	}))
+	defer server.Close()
+
+	type result struct {
+		ID int `json:"id"`
+	}
+
+	reqs := make([]BatchRequest, 0, 6)
+	for i := 0; i < 5; i++ {
+		reqs = append(reqs, BatchRequest{
+			Method: GET,
+			URL:    server.URL,
+			Opts: []Option{
+				WithQueryFromMap(map[string]any{"id": i}),
+				WithOK().ToJSON(&result{}),
+			},
+		})
+	}
+	reqs = append(reqs, BatchRequest{Method: GET, URL: server.URL})
+
+	results := DoAll(context.Background(), reqs, 3)
+	require.Len(t, results, len(reqs))
+
+	for i, got := range results[:5] {
+		assert.Equal(t, i, got.Index)
+		require.NoError(t, got.Err)
+		assert.Equal(t, &result{ID: i}, got.Value)
+	}
+
+	assert.Equal(t, 5, results[5].Index)
+	require.ErrorIs(t, results[5].Err, ErrUnhandledStatus(http.StatusBadRequest))
+	assert.Nil(t, results[5].Value)
+}
+
+func Test_DoAll_canceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		cancel()
+	}))
+	defer server.Close()
+
+	reqs := make([]BatchRequest, 5)
+	for i := range reqs {
+		reqs[i] = BatchRequest{
+			Method: GET,
+			URL:    server.URL,
+			Opts: []Option{
+				WithContext(context.Background()), // the request finishes regardless
+				WithExpectStatus(http.StatusOK),
+			},
+		}
+	}
+
+	results := DoAll(ctx, reqs, 1)
+	require.NoError(t, results[0].Err)
+	for _, got := range results[2:] {
+		require.ErrorIs(t, got.Err, context.Canceled)
+	}
+}
@@ -0,0 +1,73 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrBodyReadTimeout is wrapped by [TimeoutError] returned by reading
+// the response body after the timeout set by [WithBodyReadTimeout] elapsed.
+var ErrBodyReadTimeout = errors.New("response body read timeout")
+
+// timeoutBody closes the response body when the timeout elapses, so the read
+// blocked by a slow server returns, and reports [TimeoutError] instead of
+// the error caused by closing the body.
+type timeoutBody struct {
+	body  io.ReadCloser
+	timer *time.Timer
+
+	mu  sync.Mutex
+	err error
+}
+
+// limitBodyReadTime makes the body of the given response fail to read after
+// the given timeout. It is a no-op for non-positive timeout.
+func limitBodyReadTime(resp *http.Response, timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+
+	b := &timeoutBody{body: resp.Body}
+	b.timer = time.AfterFunc(timeout, func() {
+		b.mu.Lock()
+		b.err = &TimeoutError{Limit: TimeoutBodyRead, Elapsed: timeout, Err: ErrBodyReadTimeout}
+		b.mu.Unlock()
+
+		_ = b.body.Close()
+	})
+
+	resp.Body = b
+}
+
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return n, b.err
+	}
+
+	return n, err
+}
+
+func (b *timeoutBody) Close() error {
+	if !b.timer.Stop() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if b.err != nil {
+			// The body has been already closed by the timer.
+			return nil
+		}
+	}
+
+	return b.body.Close()
+}
@@ -0,0 +1,56 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithBodyReadTimeout(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "first")
+		if r.URL.Path == "/fast" {
+			return
+		}
+
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	var got strings.Builder
+	err := Get(server.URL+"/fast",
+		WithBodyReadTimeout(time.Second),
+		WithOK().ToWriter(&got),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "first", got.String())
+
+	start := time.Now()
+	err = Get(server.URL+"/slow",
+		WithBodyReadTimeout(50*time.Millisecond),
+		WithOK().ToWriter(io.Discard),
+	)
+	require.ErrorIs(t, err, ErrBodyReadTimeout)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	var timeoutErr *TimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.Equal(t, TimeoutBodyRead, timeoutErr.Limit)
+}
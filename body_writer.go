@@ -0,0 +1,54 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"errors"
+	"io"
+)
+
+// BodyWriterFunc writes the body content to the given writer,
+// see [WithBodyWriterFunc].
+type BodyWriterFunc func(w io.Writer) error
+
+// errBodyWriterStopped is returned by the writes of [BodyWriterFunc]
+// after the request has been completed or failed.
+var errBodyWriterStopped = errors.New("request body writer stopped")
+
+// pipeBody is the request body written by [BodyWriterFunc] running in
+// a separate goroutine.
+type pipeBody struct {
+	*io.PipeReader
+	done chan struct{}
+	err  error
+}
+
+func startBodyWriter(write BodyWriterFunc) *pipeBody {
+	pr, pw := io.Pipe()
+	body := &pipeBody{PipeReader: pr, done: make(chan struct{})}
+
+	go func() {
+		defer close(body.done)
+
+		body.err = write(pw)
+		// The nil error results in io.EOF for the reader.
+		_ = pw.CloseWithError(body.err)
+	}()
+
+	return body
+}
+
+// stop unblocks the pending writes, waits for the goroutine to exit,
+// and returns the error of [BodyWriterFunc] if it has failed by itself,
+// not because the reader has gone.
+func (b *pipeBody) stop() error {
+	_ = b.CloseWithError(errBodyWriterStopped)
+	<-b.done
+
+	if errors.Is(b.err, errBodyWriterStopped) || errors.Is(b.err, io.ErrClosedPipe) {
+		return nil
+	}
+
+	return b.err
+}
@@ -0,0 +1,133 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// RequestBuilder is a fluent alternative to the variadic options of [Do].
+// It accumulates the same options that [Do] accepts, so both styles behave
+// identically. Errors are collected until [RequestBuilder.Do] is called.
+type RequestBuilder struct {
+	httpMethod HTTPMethod
+	url        string
+	opts       []Option
+	errs       []error
+}
+
+// NewRequest creates [RequestBuilder] given [HTTPMethod] and URL.
+func NewRequest(httpMethod HTTPMethod, url string) *RequestBuilder {
+	return &RequestBuilder{
+		httpMethod: httpMethod,
+		url:        url,
+	}
+}
+
+func (b *RequestBuilder) joinErrors(errs ...error) *RequestBuilder {
+	b.errs = append(b.errs, errs...)
+	return b
+}
+
+// With adds the given options.
+func (b *RequestBuilder) With(opts ...Option) *RequestBuilder {
+	b.opts = append(b.opts, opts...)
+	return b
+}
+
+// Client sets [net/http.Client], see [WithClient].
+func (b *RequestBuilder) Client(c *http.Client) *RequestBuilder {
+	return b.With(WithClient(c))
+}
+
+// Path appends the given paths to the URL, see [WithURLPaths].
+func (b *RequestBuilder) Path(paths ...string) *RequestBuilder {
+	return b.With(WithURLPaths(paths...))
+}
+
+// Query adds a query string encoded from the given data, see [WithQuery].
+func (b *RequestBuilder) Query(data any) *RequestBuilder {
+	return b.With(WithQuery(data))
+}
+
+// Header sets the HTTP header, see [WithHeader].
+func (b *RequestBuilder) Header(
+	key HeaderKey,
+	value string,
+	appendMode ...HeaderAppendMode,
+) *RequestBuilder {
+	return b.With(WithHeader(key, value, appendMode...))
+}
+
+// Auth sets the HTTP Authorization request header, see [WithAuth].
+func (b *RequestBuilder) Auth(value string) *RequestBuilder {
+	return b.With(WithAuth(value))
+}
+
+// BasicAuth sets the HTTP Basic Authentication, see [WithBasicAuth].
+func (b *RequestBuilder) BasicAuth(username, password string) *RequestBuilder {
+	return b.With(WithBasicAuth(username, password))
+}
+
+// Body adds the given data as the body content, see [WithBody].
+func (b *RequestBuilder) Body(data io.Reader) *RequestBuilder {
+	return b.With(WithBody(data))
+}
+
+// JSON encodes the given data in JSON format as the body content,
+// see [WithJSON].
+func (b *RequestBuilder) JSON(data any) *RequestBuilder {
+	return b.With(WithJSON(data))
+}
+
+// XML encodes the given data in XML format as the body content,
+// see [WithXML].
+func (b *RequestBuilder) XML(data any) *RequestBuilder {
+	return b.With(WithXML(data))
+}
+
+// OnOK stores JSON-decoded body of the successful HTTP response to the value
+// pointed to by the given result, see [WithOK] and [OKStatuses.ToJSON].
+// By default, [net/http.StatusOK] is used as the successful HTTP status code.
+func (b *RequestBuilder) OnOK(result any, statuses ...int) *RequestBuilder {
+	if result == nil {
+		return b.joinErrors(errors.New("OK result is nil"))
+	}
+
+	return b.With(WithOK(statuses...).ToJSON(result))
+}
+
+// OnError stores JSON-decoded body of the error HTTP response with the given
+// status code to the value pointed to by the given target and returns
+// the target from [RequestBuilder.Do], like [ErrorStatuses.ToJSON] does.
+// The target must be a non-nil pointer implementing the error interface.
+func (b *RequestBuilder) OnError(status int, target error) *RequestBuilder {
+	if target == nil {
+		return b.joinErrors(errors.New("error target is nil"))
+	}
+
+	// The JSON decoder decodes to the pointer held by the error interface.
+	return b.With(func(params *doParams) error {
+		statuses := ErrorStatuses[error](withStatuses(status))
+		return statuses.to(params.handler.decodeJSON, func() *error { return &target })(params)
+	})
+}
+
+// Do sends the HTTP request with the given context and the accumulated
+// options. If any errors are collected, they are returned joined without
+// sending the request.
+func (b *RequestBuilder) Do(ctx context.Context) error {
+	if len(b.errs) > 0 {
+		return errors.Join(b.errs...)
+	}
+
+	opts := make([]Option, 0, len(b.opts)+1)
+	opts = append(opts, WithContext(ctx))
+	opts = append(opts, b.opts...)
+
+	return Do(b.httpMethod, b.url, opts...)
+}
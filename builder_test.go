@@ -0,0 +1,71 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type apiError struct {
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+func Test_RequestBuilder(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users/42" || r.URL.Query().Get("verbose") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(apiError{Message: "bad request"})
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]string{"name": r.Header.Get("X-Name")})
+	}))
+	defer server.Close()
+
+	type query struct {
+		Verbose bool `url:"verbose"`
+	}
+
+	var (
+		out    map[string]string
+		apiErr apiError
+	)
+
+	err := NewRequest(GET, server.URL).
+		Path("users", FromInt(42)).
+		Query(query{Verbose: true}).
+		Header("x-name", "gopher").
+		OnOK(&out).
+		OnError(http.StatusBadRequest, &apiErr).
+		Do(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "gopher"}, out)
+
+	err = NewRequest(GET, server.URL).
+		Path("unknown").
+		OnOK(&out).
+		OnError(http.StatusBadRequest, &apiErr).
+		Do(context.Background())
+	require.ErrorIs(t, err, &apiErr)
+	assert.Equal(t, "bad request", apiErr.Message)
+
+	err = NewRequest(GET, server.URL).
+		OnOK(nil).
+		OnError(http.StatusBadRequest, nil).
+		Do(context.Background())
+	require.Error(t, err)
+}
@@ -0,0 +1,65 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// ByteCounter counts the bytes of the request and response bodies,
+// see [WithByteCounter]. The same counter can be shared across requests,
+// including concurrent ones; in the latter case, read the counters
+// by [sync/atomic.LoadInt64] until the requests are done.
+type ByteCounter struct {
+	// Sent is the number of bytes of the request bodies read by the client,
+	// including all attempts and redirects.
+	Sent int64
+	// Received is the number of bytes of the response bodies read
+	// by the handlers, including the drained rest of the bodies.
+	Received int64
+}
+
+// countRequest makes the body of the given request counted, keeping
+// its length. It is a no-op for nil counter.
+func (c *ByteCounter) countRequest(req *http.Request) {
+	if c == nil || req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	req.Body = countingBody{ReadCloser: req.Body, n: &c.Sent}
+
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil || body == http.NoBody {
+				return body, err
+			}
+
+			return countingBody{ReadCloser: body, n: &c.Sent}, nil
+		}
+	}
+}
+
+// countResponse makes the body of the given response counted.
+// It is a no-op for nil counter.
+func (c *ByteCounter) countResponse(resp *http.Response) {
+	if c == nil {
+		return
+	}
+
+	resp.Body = countingBody{ReadCloser: resp.Body, n: &c.Received}
+}
+
+type countingBody struct {
+	io.ReadCloser
+	n *int64
+}
+
+func (b countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(b.n, int64(n))
+	return n, err
+}
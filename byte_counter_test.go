@@ -0,0 +1,56 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithByteCounter(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength != int64(len("payload")) {
+			w.WriteHeader(http.StatusLengthRequired)
+			return
+		}
+
+		_, _ = io.Copy(io.Discard, r.Body)
+		_, _ = io.WriteString(w, `{"id":42}`+"\n")
+	}))
+	defer server.Close()
+
+	var counter ByteCounter
+	var got struct {
+		ID int `json:"id"`
+	}
+
+	err := Post(server.URL,
+		WithTextPlain("payload"),
+		WithByteCounter(&counter),
+		WithOK().ToJSON(&got),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 42, got.ID)
+	assert.Equal(t, ByteCounter{Sent: 7, Received: 10}, counter)
+
+	var debug strings.Builder
+
+	err = Post(server.URL,
+		WithBody(strings.NewReader("payload")),
+		WithByteCounter(&counter),
+		WithDebug(&debug),
+		WithOK().ToDiscard(),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, ByteCounter{Sent: 14, Received: 20}, counter)
+	assert.Contains(t, debug.String(), `{"id":42}`)
+}
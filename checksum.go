@@ -0,0 +1,206 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"bytes"
+	"crypto/md5" //nolint:gosec // Content-MD5 is required by some APIs, not for security
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+// ErrBodyNotSeekable is returned when a checksum of the body is required,
+// but the body cannot be rewound after computing it.
+var ErrBodyNotSeekable = errors.New(
+	"checksum requires a seekable body (io.ReadSeeker), e.g., set by WithBytes",
+)
+
+// bodyChecksum is a checksum of the body to send in the given header.
+type bodyChecksum struct {
+	header HeaderKey
+
+	// algorithm is the name of the algorithm prepended to the checksum,
+	// if not empty, e.g., "SHA-256=".
+	algorithm string
+
+	newHash func() hash.Hash
+}
+
+var digestAlgorithms = map[string]func() hash.Hash{
+	"MD5":     md5.New,
+	"SHA-256": sha256.New,
+	"SHA-512": sha512.New,
+}
+
+func newContentMD5Checksum() bodyChecksum {
+	return bodyChecksum{header: HeaderContentMD5, newHash: md5.New}
+}
+
+func newDigestChecksum(algorithm string) (bodyChecksum, error) {
+	name := strings.ToUpper(algorithm)
+
+	newHash, ok := digestAlgorithms[name]
+	if !ok {
+		return bodyChecksum{}, fmt.Errorf("unsupported digest algorithm %q", algorithm)
+	}
+
+	return bodyChecksum{header: HeaderDigest, algorithm: name, newHash: newHash}, nil
+}
+
+// applyChecksums computes the checksums of the body, sets the headers,
+// and rewinds the body to the initial position.
+func (params *doParams) applyChecksums() error {
+	if len(params.checksums) == 0 {
+		return nil
+	}
+
+	if params.bodyWriter != nil {
+		return ErrBodyNotSeekable
+	}
+
+	var body io.ReadSeeker = strings.NewReader("")
+	if params.body != nil {
+		seeker, ok := params.body.(io.ReadSeeker)
+		if !ok {
+			return ErrBodyNotSeekable
+		}
+		body = seeker
+	}
+
+	offset, err := body.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	hashes := make([]hash.Hash, 0, len(params.checksums))
+	writers := make([]io.Writer, 0, len(params.checksums))
+	for _, checksum := range params.checksums {
+		h := checksum.newHash()
+		hashes = append(hashes, h)
+		writers = append(writers, h)
+	}
+
+	if _, err := io.Copy(io.MultiWriter(writers...), body); err != nil {
+		return err
+	}
+
+	if _, err := body.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	for i, checksum := range params.checksums {
+		value := base64.StdEncoding.EncodeToString(hashes[i].Sum(nil))
+		if checksum.algorithm != "" {
+			value = checksum.algorithm + "=" + value
+		}
+
+		key := string(checksum.header)
+		params.headers[key] = append(params.headers[key], value)
+	}
+
+	return nil
+}
+
+// ChecksumMismatchError is an error for the response body whose checksum
+// differs from the expected one, see [WithVerifyChecksum].
+type ChecksumMismatchError struct {
+	// Algorithm is the canonical name of the algorithm, e.g., "SHA-256".
+	Algorithm string
+
+	// Expected and Actual are the hex-encoded checksums.
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf(
+		"%s checksum mismatch: expected %s, got %s", e.Algorithm, e.Expected, e.Actual,
+	)
+}
+
+var _ error = (*ChecksumMismatchError)(nil)
+
+// lookupDigestAlgorithm returns the canonical name and the hash constructor
+// of the given algorithm, ignoring case and dashes, e.g., "sha256".
+func lookupDigestAlgorithm(algorithm string) (string, func() hash.Hash, bool) {
+	normalize := func(name string) string {
+		return strings.ReplaceAll(strings.ToUpper(name), "-", "")
+	}
+
+	for name, newHash := range digestAlgorithms {
+		if normalize(name) == normalize(algorithm) {
+			return name, newHash, true
+		}
+	}
+
+	return "", nil, false
+}
+
+// verifyingBody hashes the response body while it is read and compares
+// the checksum with the expected one when the body is fully consumed.
+type verifyingBody struct {
+	body      io.ReadCloser
+	hash      hash.Hash
+	algorithm string
+	expected  []byte
+
+	// verified reports whether the checksum has been compared.
+	verified bool
+	err      error
+}
+
+func (v *verifyingBody) Read(p []byte) (int, error) {
+	n, err := v.body.Read(p)
+	v.hash.Write(p[:n])
+
+	if errors.Is(err, io.EOF) && !v.verified {
+		v.verified = true
+
+		if actual := v.hash.Sum(nil); !bytes.Equal(actual, v.expected) {
+			v.err = &ChecksumMismatchError{
+				Algorithm: v.algorithm,
+				Expected:  hex.EncodeToString(v.expected),
+				Actual:    hex.EncodeToString(actual),
+			}
+		}
+	}
+
+	if v.err != nil {
+		return n, v.err
+	}
+
+	return n, err
+}
+
+// Close drains the rest of the body through the hash, so the checksum
+// is verified even if the body has not been fully read, and closes the body.
+// It returns the mismatch error even if it has already been returned by Read,
+// since the reader may have been draining the body and ignored it.
+// The drain error is returned as [wrapperCloseError], since the checksum
+// has not been verified.
+func (v *verifyingBody) Close() error {
+	var drainErr error
+	if !v.verified {
+		if _, err := io.Copy(io.Discard, v); err != nil && !errors.Is(err, v.err) {
+			drainErr = &wrapperCloseError{err: err}
+		}
+	}
+
+	if err := v.body.Close(); err != nil {
+		return errors.Join(v.err, drainErr, err)
+	}
+
+	if v.err != nil {
+		return v.err
+	}
+
+	return drainErr
+}
@@ -0,0 +1,125 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_applyChecksums(t *testing.T) {
+	t.Parallel()
+
+	params, err := newDoParams(
+		WithDigest("sha-256"),
+		WithTextPlain("hello"),
+		WithContentMD5(),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"XUFAKrxLKna5cZ2REBfFkg=="},
+		params.headers[string(HeaderContentMD5)])
+	assert.Equal(t, []string{"SHA-256=LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ="},
+		params.headers[string(HeaderDigest)])
+
+	body, err := io.ReadAll(params.body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+
+	_, err = newDoParams(WithContentMD5(), WithBody(io.MultiReader(strings.NewReader("x"))))
+	require.ErrorIs(t, err, ErrBodyNotSeekable)
+
+	_, err = newDoParams(WithDigest("crc32"))
+	require.Error(t, err)
+}
+
+func Test_WithVerifyChecksum(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("artifact", 1024)
+	sum := sha256.Sum256([]byte(content))
+	expected := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/json" {
+			_, _ = w.Write([]byte(`{"name":"artifact"}` + strings.Repeat(" ", 1024)))
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	var got strings.Builder
+
+	err := Get(server.URL,
+		WithVerifyChecksum("sha256", strings.ToUpper(expected)),
+		WithOK().ToWriter(&got),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, content, got.String())
+
+	err = Get(server.URL,
+		WithVerifyChecksum("SHA-512", expected),
+		WithOK().ToDiscard(),
+	)
+	var mismatch *ChecksumMismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, "SHA-512", mismatch.Algorithm)
+	assert.Equal(t, expected, mismatch.Expected)
+	assert.Equal(t, 1, strings.Count(err.Error(), "checksum mismatch"))
+
+	// The JSON decoder stops before the trailing spaces, so the mismatch
+	// is detected while closing the body.
+	var result struct{ Name string }
+	err = Get(server.URL+"/json",
+		WithVerifyChecksum("md5", expected[:32]),
+		WithNoDrain(),
+		WithOK().ToJSON(&result),
+	)
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, "artifact", result.Name)
+
+	err = Get(server.URL, WithVerifyChecksum("crc32", expected))
+	require.Error(t, err)
+
+	err = Get(server.URL, WithVerifyChecksum("sha256", "not hex"))
+	require.Error(t, err)
+}
+
+func Test_WithVerifyChecksum_drainError(t *testing.T) {
+	t.Parallel()
+
+	errRead := errors.New("read failed")
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body: io.NopCloser(io.MultiReader(
+				strings.NewReader("artifact"),
+				iotest.ErrReader(errRead),
+			)),
+			Request: r,
+		}, nil
+	})
+
+	// The handler does not read the body, so the checksum cannot be verified
+	// while draining it, and the drain error is returned.
+	var result struct{}
+	err := Get("https://example.com",
+		WithClient(&http.Client{Transport: transport}),
+		WithVerifyChecksum("md5", strings.Repeat("0", 32)),
+		WithOK().To(&result, func(io.Reader, any) error { return nil }),
+	)
+	require.ErrorIs(t, err, errRead)
+}
@@ -0,0 +1,312 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"container/list"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// optional is a value that is either set or not.
+type optional[T any] struct {
+	value T
+	isSet bool
+}
+
+func some[T any](value T) optional[T] {
+	return optional[T]{value: value, isSet: true}
+}
+
+// transportConfig holds the settings of [net/http.Transport] for the current
+// request. It is comparable, so the transports modified by the same settings
+// can be cached.
+type transportConfig struct {
+	expectContinueTimeout optional[time.Duration]
+	maxIdleConnsPerHost   optional[int]
+	maxConnsPerHost       optional[int]
+	idleConnTimeout       optional[time.Duration]
+	dialTimeout           optional[time.Duration]
+	localAddr             optional[netip.Addr]
+	unixSocket            optional[string]
+	responseHeaderTimeout optional[time.Duration]
+	disableKeepAlives     optional[bool]
+	forceHTTP1            optional[bool]
+	http2PriorKnowledge   optional[bool]
+}
+
+func (c *transportConfig) apply(t *http.Transport) {
+	if c.expectContinueTimeout.isSet {
+		t.ExpectContinueTimeout = c.expectContinueTimeout.value
+	}
+	if c.maxIdleConnsPerHost.isSet {
+		t.MaxIdleConnsPerHost = c.maxIdleConnsPerHost.value
+	}
+	if c.maxConnsPerHost.isSet {
+		t.MaxConnsPerHost = c.maxConnsPerHost.value
+	}
+	if c.idleConnTimeout.isSet {
+		t.IdleConnTimeout = c.idleConnTimeout.value
+	}
+	if c.disableKeepAlives.isSet {
+		t.DisableKeepAlives = c.disableKeepAlives.value
+	}
+	if c.localAddr.isSet {
+		t.DialContext = dialFromLocalAddr(c.localAddr.value)
+	}
+	if c.unixSocket.isSet {
+		t.DialContext = dialUnixSocket(c.unixSocket.value)
+		// The proxy from the environment would receive the connection instead.
+		t.Proxy = nil
+	}
+	if c.dialTimeout.isSet {
+		t.DialContext = dialWithTimeout(t.DialContext, c.dialTimeout.value)
+	}
+	if c.responseHeaderTimeout.isSet {
+		t.ResponseHeaderTimeout = c.responseHeaderTimeout.value
+	}
+	if c.forceHTTP1.value {
+		// A non-nil empty map disables HTTP/2.
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		if t.TLSClientConfig != nil {
+			t.TLSClientConfig = t.TLSClientConfig.Clone()
+			t.TLSClientConfig.NextProtos = []string{"http/1.1"}
+		}
+	}
+}
+
+type dialFunc = func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// dialWithTimeout returns the given dial function, or the default one if it is
+// nil, bounded by the given timeout. Only the connection setup is bounded,
+// not the lifetime of the connection.
+func dialWithTimeout(dial dialFunc, timeout time.Duration) dialFunc {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return dial(ctx, network, addr)
+	}
+}
+
+// dialFromLocalAddr returns the dial function that binds the connection
+// to the given local IP address. The dialer has the same settings as the one
+// of [net/http.DefaultTransport].
+func dialFromLocalAddr(ip netip.Addr) dialFunc {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		LocalAddr: &net.TCPAddr{IP: ip.AsSlice(), Zone: ip.Zone()},
+	}
+
+	return dialer.DialContext
+}
+
+// dialUnixSocket returns the dial function that connects to the Unix domain
+// socket at the given path regardless of the network and address.
+func dialUnixSocket(path string) dialFunc {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", path)
+	}
+}
+
+// roundTripper returns the given transport, or the HTTP/2 transport derived
+// from it if HTTP/2 with prior knowledge is required.
+func (c *transportConfig) roundTripper(t *http.Transport) http.RoundTripper {
+	if !c.http2PriorKnowledge.value {
+		return t
+	}
+
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	return &http2.Transport{
+		AllowHTTP:       true,
+		TLSClientConfig: t.TLSClientConfig,
+		DialTLSContext: func(
+			ctx context.Context,
+			network, addr string,
+			_ *tls.Config,
+		) (net.Conn, error) {
+			return dial(ctx, network, addr)
+		},
+	}
+}
+
+var (
+	errNotHTTPTransport = errors.New(
+		"transport options require the client transport to be *http.Transport",
+	)
+	errConflictingProtocols = errors.New(
+		"HTTP/2 with prior knowledge and forced HTTP/1.1 are mutually exclusive",
+	)
+)
+
+type transportCacheKey struct {
+	base   *http.Transport
+	config transportConfig
+}
+
+// maxCachedTransports bounds the number of the cached clones of the transports,
+// so the clients created, e.g., per tenant do not pin the transports and their
+// idle connections forever.
+const maxCachedTransports = 64
+
+// transportCache holds the modified clones of the transports, so the same
+// settings share the same connection pool across requests.
+var transportCache = newTransportLRU(maxCachedTransports)
+
+// transportLRU is the cache of the transports that evicts the least recently
+// used one when it is full, closing its idle connections.
+type transportLRU struct {
+	mu      sync.Mutex
+	limit   int
+	entries map[transportCacheKey]*list.Element
+	order   *list.List // of *transportEntry, the most recently used first
+}
+
+type transportEntry struct {
+	key       transportCacheKey
+	transport http.RoundTripper
+}
+
+func newTransportLRU(limit int) *transportLRU {
+	return &transportLRU{
+		limit:   limit,
+		entries: make(map[transportCacheKey]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns the transport cached by the given key, or caches and returns
+// the one created by the given function.
+func (c *transportLRU) get(
+	key transportCacheKey,
+	create func() http.RoundTripper,
+) http.RoundTripper {
+	c.mu.Lock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		c.mu.Unlock()
+
+		entry, _ := elem.Value.(*transportEntry)
+		return entry.transport
+	}
+
+	transport := create()
+	c.entries[key] = c.order.PushFront(&transportEntry{key: key, transport: transport})
+
+	var evicted http.RoundTripper
+	if c.order.Len() > c.limit {
+		oldest, _ := c.order.Remove(c.order.Back()).(*transportEntry)
+		delete(c.entries, oldest.key)
+		evicted = oldest.transport
+	}
+
+	c.mu.Unlock()
+
+	// The requests in flight keep using the evicted transport until they are done.
+	if closer, ok := evicted.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+
+	return transport
+}
+
+// cloneClient returns a shallow copy of the given client whose transport
+// is a clone of the client transport modified by the given settings,
+// so that neither the given client nor its transport is changed. If the client
+// transport is nil, [net/http.DefaultTransport] is cloned.
+//
+// The clones are cached by the original transport and the settings,
+// so the connections are reused across requests. At most
+// [maxCachedTransports] clones are kept, and the idle connections of
+// the evicted ones are closed.
+func cloneClient(c *http.Client, config transportConfig) (*http.Client, error) {
+	if config == (transportConfig{}) {
+		return c, nil
+	}
+
+	if config.forceHTTP1.value && config.http2PriorKnowledge.value {
+		return nil, errConflictingProtocols
+	}
+
+	roundTripper := c.Transport
+	if roundTripper == nil {
+		roundTripper = http.DefaultTransport
+	}
+
+	base, ok := roundTripper.(*http.Transport)
+	if !ok {
+		return nil, errNotHTTPTransport
+	}
+
+	key := transportCacheKey{base: base, config: config}
+
+	transport := transportCache.get(key, func() http.RoundTripper {
+		clone := base.Clone()
+		config.apply(clone)
+
+		return config.roundTripper(clone)
+	})
+
+	clone := *c
+	clone.Transport = transport
+
+	return &clone, nil
+}
+
+// ErrCrossHostRedirect is returned when the redirect leaves the original host,
+// see [WithSameHostRedirects].
+var ErrCrossHostRedirect = errors.New("redirect to another host is not allowed")
+
+// maxRedirects is the number of redirects followed by [net/http.Client]
+// with the nil CheckRedirect.
+const maxRedirects = 10
+
+// withSameHostRedirects returns a shallow copy of the given client that
+// follows the redirects only to the host of the original request. The client
+// redirect policy, if any, is still applied to the allowed redirects.
+func withSameHostRedirects(c *http.Client) *http.Client {
+	checkRedirect := c.CheckRedirect
+
+	clone := *c
+	clone.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if host := via[0].URL.Host; !strings.EqualFold(req.URL.Host, host) {
+			return fmt.Errorf("%w: from %s to %s", ErrCrossHostRedirect, host, req.URL.Host)
+		}
+
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+
+		return nil
+	}
+
+	return &clone
+}
@@ -0,0 +1,251 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func Test_cloneClient(t *testing.T) {
+	t.Parallel()
+
+	base := &http.Transport{
+		TLSClientConfig: &tls.Config{ServerName: "example.com", MinVersion: tls.VersionTLS12},
+	}
+	client := &http.Client{Transport: base, Timeout: time.Second}
+
+	same, err := cloneClient(client, transportConfig{})
+	require.NoError(t, err)
+	assert.Same(t, client, same)
+
+	config := transportConfig{
+		maxIdleConnsPerHost: some(100),
+		disableKeepAlives:   some(true),
+	}
+
+	clone, err := cloneClient(client, config)
+	require.NoError(t, err)
+	assert.NotSame(t, client, clone)
+	assert.Equal(t, time.Second, clone.Timeout)
+
+	transport, ok := clone.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.NotSame(t, base, transport)
+	assert.Equal(t, "example.com", transport.TLSClientConfig.ServerName)
+	assert.Equal(t, 100, transport.MaxIdleConnsPerHost)
+	assert.True(t, transport.DisableKeepAlives)
+	assert.Equal(t, 0, base.MaxIdleConnsPerHost)
+	assert.False(t, base.DisableKeepAlives)
+
+	cached, err := cloneClient(client, config)
+	require.NoError(t, err)
+	assert.Same(t, transport, cached.Transport)
+
+	_, err = cloneClient(&http.Client{Transport: roundTripperFunc(nil)}, config)
+	require.ErrorIs(t, err, errNotHTTPTransport)
+}
+
+func Test_cloneClient_reusesConnections(t *testing.T) {
+	t.Parallel()
+
+	var newConns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {},
+	))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			newConns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{}}
+	for i := 0; i < 3; i++ {
+		err := Get(server.URL,
+			WithClient(client),
+			WithMaxIdleConnsPerHost(4),
+			WithOK().ToDiscard(),
+		)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(1), newConns.Load())
+}
+
+type idleClosingTransport struct {
+	roundTripperFunc
+	closed bool
+}
+
+func (t *idleClosingTransport) CloseIdleConnections() {
+	t.closed = true
+}
+
+func Test_transportLRU(t *testing.T) {
+	t.Parallel()
+
+	cache := newTransportLRU(2)
+	base := &http.Transport{}
+	key := func(n int) transportCacheKey {
+		return transportCacheKey{base: base, config: transportConfig{maxIdleConnsPerHost: some(n)}}
+	}
+
+	transports := make([]*idleClosingTransport, 3)
+	for i := range transports {
+		transports[i] = &idleClosingTransport{}
+	}
+
+	get := func(n int) http.RoundTripper {
+		return cache.get(key(n), func() http.RoundTripper { return transports[n] })
+	}
+
+	assert.Same(t, transports[0], get(0))
+	assert.Same(t, transports[1], get(1))
+	assert.Same(t, transports[0], get(0)) // 1 is the least recently used now
+
+	assert.Same(t, transports[2], get(2))
+	assert.True(t, transports[1].closed)
+	assert.False(t, transports[0].closed)
+	assert.Len(t, cache.entries, 2)
+	assert.Equal(t, 2, cache.order.Len())
+
+	transports[1] = &idleClosingTransport{}
+	assert.Same(t, transports[1], get(1))
+	assert.True(t, transports[0].closed)
+	assert.False(t, transports[2].closed)
+}
+
+func Test_WithClientAndContextNil(t *testing.T) {
+	t.Parallel()
+
+	err := Get("https://example.com", WithClient(nil))
+	require.ErrorContains(t, err, "WithClient")
+
+	//nolint:staticcheck // checks the nil context
+	err = Get("https://example.com", WithContext(nil))
+	require.ErrorContains(t, err, "WithContext")
+
+	err = (&doParams{}).prepareURL()
+	require.EqualError(t, err, "rqx: context is nil")
+
+	err = (&doParams{ctx: context.Background()}).prepareURL()
+	require.EqualError(t, err, "rqx: client is nil")
+}
+
+func Test_WithResponseHeaderTimeout(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	err := Get(server.URL,
+		WithResponseHeaderTimeout(50*time.Millisecond),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timeout awaiting response headers")
+}
+
+func Test_dialWithTimeout(t *testing.T) {
+	t.Parallel()
+
+	var deadline time.Time
+	dial := func(ctx context.Context, _, _ string) (net.Conn, error) {
+		deadline, _ = ctx.Deadline()
+		return nil, errors.New("dial failed")
+	}
+
+	start := time.Now()
+	_, err := dialWithTimeout(dial, time.Minute)(context.Background(), "tcp", "example.com:80")
+	require.Error(t, err)
+	assert.WithinDuration(t, start.Add(time.Minute), deadline, time.Second)
+}
+
+func Test_WithLocalAddr(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("binding to 127.0.0.2 requires the whole 127.0.0.0/8 loopback range")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		_, _ = io.WriteString(w, host)
+	}))
+	defer server.Close()
+
+	var got strings.Builder
+	err := Get(server.URL,
+		WithLocalAddr("127.0.0.2"),
+		WithDialTimeout(time.Second),
+		WithOK().ToWriter(&got),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.2", got.String())
+
+	err = Get(server.URL, WithLocalAddr("127.0.0.256"))
+	assert.ErrorContains(t, err, "invalid local address")
+}
+
+func Test_WithUnixSocket(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix domain sockets are not supported on all Windows versions")
+	}
+
+	socket := filepath.Join(t.TempDir(), "api.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Host+r.URL.Path)
+	})
+	server := httptest.NewUnstartedServer(handler)
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	var got strings.Builder
+	err = Get("http://docker/v1.45/containers/json",
+		WithUnixSocket(socket),
+		WithDialTimeout(time.Second),
+		WithOK().ToWriter(&got),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "docker/v1.45/containers/json", got.String())
+
+	err = Get("http://docker/_ping", WithUnixSocket(filepath.Join(t.TempDir(), "missing.sock")))
+	var connErr *ConnectionError
+	require.ErrorAs(t, err, &connErr)
+
+	err = Get("http://docker/_ping", WithUnixSocket(""))
+	require.Error(t, err)
+}
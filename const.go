@@ -72,6 +72,72 @@ const (
 	PATCH HTTPMethod = "PATCH"
 )
 
+// WebDAV methods defined by [RFC 4918]. None of them is cacheable.
+//
+// [RFC 4918]: https://www.rfc-editor.org/rfc/rfc4918
+const (
+	// The PROPFIND method retrieves properties of the specified resource
+	// and, depending on the Depth header, of its members.
+	//
+	// Semantics:
+	//  - Safe ✅
+	//  - Idempotent ✅
+	PROPFIND HTTPMethod = "PROPFIND"
+
+	// The PROPPATCH method sets and/or removes properties of the specified
+	// resource.
+	//
+	// Semantics:
+	//  - Safe ❌
+	//  - Idempotent ✅
+	PROPPATCH HTTPMethod = "PROPPATCH"
+
+	// The MKCOL method creates a new collection at the specified location.
+	//
+	// Semantics:
+	//  - Safe ❌
+	//  - Idempotent ✅
+	MKCOL HTTPMethod = "MKCOL"
+
+	// The COPY method creates a duplicate of the specified resource
+	// at the location given by the Destination header.
+	//
+	// Semantics:
+	//  - Safe ❌
+	//  - Idempotent ✅
+	COPY HTTPMethod = "COPY"
+
+	// The MOVE method moves the specified resource to the location given
+	// by the Destination header.
+	//
+	// Semantics:
+	//  - Safe ❌
+	//  - Idempotent ✅
+	MOVE HTTPMethod = "MOVE"
+
+	// The LOCK method takes out a lock of any access type on the specified
+	// resource or refreshes an existing lock.
+	//
+	// Semantics:
+	//  - Safe ❌
+	//  - Idempotent ❌
+	LOCK HTTPMethod = "LOCK"
+
+	// The UNLOCK method removes the lock identified by the Lock-Token header.
+	//
+	// Semantics:
+	//  - Safe ❌
+	//  - Idempotent ✅
+	UNLOCK HTTPMethod = "UNLOCK"
+)
+
+// knownHTTPMethods are the methods defined by RFC 9110, RFC 5789,
+// and RFC 4918, including the ones without constants.
+var knownHTTPMethods = []HTTPMethod{
+	GET, "HEAD", POST, PUT, DELETE, "CONNECT", OPTIONS, "TRACE", PATCH,
+	PROPFIND, PROPPATCH, MKCOL, COPY, MOVE, LOCK, UNLOCK,
+}
+
 // HeaderKey is a case-insensitive name of the HTTP header.
 type HeaderKey string
 
@@ -80,6 +146,66 @@ const (
 	HeaderContentDisposition HeaderKey = "Content-Disposition"
 	HeaderAccept             HeaderKey = "Accept"
 	HeaderAuthorization      HeaderKey = "Authorization"
+	HeaderExpect             HeaderKey = "Expect"
+	HeaderXRequestID         HeaderKey = "X-Request-Id"
+	HeaderContentMD5         HeaderKey = "Content-Md5"
+	HeaderDigest             HeaderKey = "Digest"
+	HeaderRange              HeaderKey = "Range"
+	HeaderAllow              HeaderKey = "Allow"
+	HeaderContentRange       HeaderKey = "Content-Range"
+
+	HeaderAcceptCharset       HeaderKey = "Accept-Charset"
+	HeaderAcceptEncoding      HeaderKey = "Accept-Encoding"
+	HeaderAcceptLanguage      HeaderKey = "Accept-Language"
+	HeaderAcceptRanges        HeaderKey = "Accept-Ranges"
+	HeaderAge                 HeaderKey = "Age"
+	HeaderCacheControl        HeaderKey = "Cache-Control"
+	HeaderConnection          HeaderKey = "Connection"
+	HeaderContentEncoding     HeaderKey = "Content-Encoding"
+	HeaderContentLanguage     HeaderKey = "Content-Language"
+	HeaderContentLength       HeaderKey = "Content-Length"
+	HeaderContentLocation     HeaderKey = "Content-Location"
+	HeaderCookie              HeaderKey = "Cookie"
+	HeaderDate                HeaderKey = "Date"
+	HeaderETag                HeaderKey = "Etag"
+	HeaderExpires             HeaderKey = "Expires"
+	HeaderForwarded           HeaderKey = "Forwarded"
+	HeaderHost                HeaderKey = "Host"
+	HeaderIfMatch             HeaderKey = "If-Match"
+	HeaderIfModifiedSince     HeaderKey = "If-Modified-Since"
+	HeaderIfNoneMatch         HeaderKey = "If-None-Match"
+	HeaderIfRange             HeaderKey = "If-Range"
+	HeaderIfUnmodifiedSince   HeaderKey = "If-Unmodified-Since"
+	HeaderLastModified        HeaderKey = "Last-Modified"
+	HeaderLink                HeaderKey = "Link"
+	HeaderLocation            HeaderKey = "Location"
+	HeaderOrigin              HeaderKey = "Origin"
+	HeaderPragma              HeaderKey = "Pragma"
+	HeaderProxyAuthorization  HeaderKey = "Proxy-Authorization"
+	HeaderReferer             HeaderKey = "Referer"
+	HeaderRetryAfter          HeaderKey = "Retry-After"
+	HeaderSetCookie           HeaderKey = "Set-Cookie"
+	HeaderTrailer             HeaderKey = "Trailer"
+	HeaderTransferEncoding    HeaderKey = "Transfer-Encoding"
+	HeaderUserAgent           HeaderKey = "User-Agent"
+	HeaderVary                HeaderKey = "Vary"
+	HeaderWWWAuthenticate     HeaderKey = "Www-Authenticate"
+	HeaderXForwardedFor       HeaderKey = "X-Forwarded-For"
+	HeaderXHTTPMethodOverride HeaderKey = "X-Http-Method-Override"
+
+	HeaderAccessControlAllowOrigin      HeaderKey = "Access-Control-Allow-Origin"
+	HeaderAccessControlAllowMethods     HeaderKey = "Access-Control-Allow-Methods"
+	HeaderAccessControlAllowHeaders     HeaderKey = "Access-Control-Allow-Headers"
+	HeaderAccessControlAllowCredentials HeaderKey = "Access-Control-Allow-Credentials"
+	HeaderAccessControlExposeHeaders    HeaderKey = "Access-Control-Expose-Headers"
+	HeaderAccessControlMaxAge           HeaderKey = "Access-Control-Max-Age"
+
+	HeaderRateLimitLimit      HeaderKey = "Ratelimit-Limit"
+	HeaderRateLimitRemaining  HeaderKey = "Ratelimit-Remaining"
+	HeaderRateLimitReset      HeaderKey = "Ratelimit-Reset"
+	HeaderXRateLimitLimit     HeaderKey = "X-Ratelimit-Limit"
+	HeaderXRateLimitRemaining HeaderKey = "X-Ratelimit-Remaining"
+	HeaderXRateLimitReset     HeaderKey = "X-Ratelimit-Reset"
 )
 
 // ContentType is the HTTP Content-Type representation header is used to indicate
@@ -90,4 +216,11 @@ const (
 	ContentTextPlain ContentType = "text/plain"
 	ContentJSON      ContentType = "application/json"
 	ContentXML       ContentType = "application/xml"
+	ContentCSV       ContentType = "text/csv"
+
+	ContentHTML           ContentType = "text/html"
+	ContentOctetStream    ContentType = "application/octet-stream"
+	ContentFormURLEncoded ContentType = "application/x-www-form-urlencoded"
+	ContentPDF            ContentType = "application/pdf"
+	ContentPNG            ContentType = "image/png"
 )
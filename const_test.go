@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -20,6 +21,29 @@ func Test_CanonicalHeaderKeys(t *testing.T) {
 	}
 }
 
+func Test_HTTPMethods(t *testing.T) {
+	pat := regexp.MustCompile("^\\s*(\\w+)\\s+HTTPMethod\\s*=\\s*\"(\\w+)\"")
+
+	file, err := os.ReadFile("const.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	for _, line := range strings.Split(string(file), "\n") {
+		matches := pat.FindStringSubmatch(line)
+		if len(matches) != 3 {
+			continue
+		}
+
+		count++
+		assert.Equal(t, matches[1], matches[2])
+		assert.Contains(t, knownHTTPMethods, HTTPMethod(matches[2]))
+	}
+
+	assert.NotZero(t, count)
+}
+
 func allHeaderKeysFromFile(t *testing.T, filename string) []string {
 	t.Helper()
 
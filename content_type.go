@@ -0,0 +1,41 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// MatchesContentType reports whether the media type of the Content-Type
+// header of the given response matches the given content type, ignoring
+// parameters, e.g., charset, and case. A media type with a structured syntax
+// suffix matches the content type of the suffix, e.g.,
+// "application/problem+json" matches [ContentJSON], but not vice versa.
+func MatchesContentType(resp *http.Response, want ContentType) bool {
+	actual, _, err := mime.ParseMediaType(resp.Header.Get(string(HeaderContentType)))
+	if err != nil {
+		return false
+	}
+
+	expected, _, err := mime.ParseMediaType(string(want))
+	if err != nil {
+		return false
+	}
+
+	if actual == expected {
+		return true
+	}
+
+	actualType, actualSubtype, _ := strings.Cut(actual, "/")
+	expectedType, expectedSubtype, _ := strings.Cut(expected, "/")
+	if actualType != expectedType {
+		return false
+	}
+
+	_, suffix, ok := strings.Cut(actualSubtype, "+")
+
+	return ok && suffix == expectedSubtype
+}
@@ -0,0 +1,42 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MatchesContentType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		header string
+		want   ContentType
+		match  bool
+	}{
+		{header: "application/json", want: ContentJSON, match: true},
+		{header: "Application/JSON; charset=utf-8", want: ContentJSON, match: true},
+		{header: "application/problem+json", want: ContentJSON, match: true},
+		{header: "application/problem+json", want: "application/problem+json", match: true},
+		{header: "application/soap+xml", want: ContentXML, match: true},
+		{header: "application/json", want: "application/problem+json", match: false},
+		{header: "text/json", want: ContentJSON, match: false},
+		{header: "text/html; charset=utf-8", want: ContentJSON, match: false},
+		{header: "application/jsonp", want: ContentJSON, match: false},
+		{header: "", want: ContentJSON, match: false},
+		{header: "application/json", want: "", match: false},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.header != "" {
+			resp.Header.Set(string(HeaderContentType), tt.header)
+		}
+
+		assert.Equal(t, tt.match, MatchesContentType(resp, tt.want), "%s vs %s", tt.header, tt.want)
+	}
+}
@@ -0,0 +1,132 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+)
+
+// debugBodyLimit is the maximum number of bytes of the request and response
+// bodies that are dumped by [WithDebug].
+const debugBodyLimit = 16 << 10
+
+const maskedHeaderValue = "***"
+
+// maskedHeaders are the headers whose values are masked in the dumps.
+var maskedHeaders = []string{
+	string(HeaderAuthorization),
+	string(HeaderCookie),
+	string(HeaderSetCookie),
+	string(HeaderProxyAuthorization),
+}
+
+// debugger dumps the requests and responses to the writer.
+type debugger struct {
+	w       io.Writer
+	attempt int
+}
+
+func (d *debugger) dumpRequest(req *http.Request) {
+	if d == nil {
+		return
+	}
+
+	d.attempt++
+
+	clone := req.Clone(req.Context())
+	clone.Header = maskHeader(req.Header)
+
+	dump, err := httputil.DumpRequestOut(clone, false)
+	if err != nil {
+		d.printf("--- rqx: attempt %d: request: dump failed: %v\n", d.attempt, err)
+		return
+	}
+
+	d.printf("--- rqx: attempt %d: request ---\n%s%s\n", d.attempt, dump, d.requestBody(req))
+}
+
+func (d *debugger) requestBody(req *http.Request) string {
+	switch {
+	case req.Body == nil || req.Body == http.NoBody:
+		return ""
+	case req.GetBody == nil:
+		return "[non-rewindable body omitted]"
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return fmt.Sprintf("[body omitted: %v]", err)
+	}
+	defer func() { _ = body.Close() }()
+
+	prefix, err := io.ReadAll(io.LimitReader(body, debugBodyLimit+1))
+	if err != nil {
+		return fmt.Sprintf("[body omitted: %v]", err)
+	}
+
+	return truncateDebugBody(prefix)
+}
+
+func (d *debugger) dumpResponse(resp *http.Response) {
+	if d == nil {
+		return
+	}
+
+	clone := *resp
+	clone.Header = maskHeader(resp.Header)
+
+	dump, err := httputil.DumpResponse(&clone, false)
+	if err != nil {
+		d.printf("--- rqx: attempt %d: response: dump failed: %v\n", d.attempt, err)
+		return
+	}
+
+	d.printf("--- rqx: attempt %d: response ---\n%s%s\n", d.attempt, dump, responseBody(resp))
+}
+
+// responseBody reads the prefix of the response body and replays it,
+// so the handlers still can read the whole body.
+func responseBody(resp *http.Response) string {
+	prefix, err := io.ReadAll(io.LimitReader(resp.Body, debugBodyLimit+1))
+
+	resp.Body = readCloser{
+		Reader: io.MultiReader(bytes.NewReader(prefix), resp.Body),
+		Closer: resp.Body,
+	}
+
+	if err != nil {
+		return fmt.Sprintf("[body omitted: %v]", err)
+	}
+
+	return truncateDebugBody(prefix)
+}
+
+func truncateDebugBody(prefix []byte) string {
+	if len(prefix) > debugBodyLimit {
+		return string(prefix[:debugBodyLimit]) + "\n[body truncated]"
+	}
+
+	return string(prefix)
+}
+
+func (d *debugger) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(d.w, format, args...)
+}
+
+func maskHeader(header http.Header) http.Header {
+	masked := header.Clone()
+	for _, key := range maskedHeaders {
+		if values, ok := masked[key]; ok {
+			for i := range values {
+				values[i] = maskedHeaderValue
+			}
+		}
+	}
+
+	return masked
+}
@@ -0,0 +1,104 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithDebug(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"id":42}`)
+	}))
+	defer server.Close()
+
+	var (
+		dump   strings.Builder
+		result struct {
+			ID int `json:"id"`
+		}
+	)
+
+	err := Post(server.URL,
+		WithDebug(&dump),
+		WithBasicAuth("user", "secret"),
+		WithTextPlain("request body"),
+		WithOK().ToJSON(&result),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 42, result.ID)
+
+	out := dump.String()
+	assert.Contains(t, out, "--- rqx: attempt 1: request ---")
+	assert.Contains(t, out, "Authorization: ***")
+	assert.NotContains(t, out, "Basic ")
+	assert.Contains(t, out, "request body")
+	assert.Contains(t, out, "--- rqx: attempt 1: response ---")
+	assert.Contains(t, out, `{"id":42}`)
+
+	dump.Reset()
+
+	err = Post(server.URL,
+		WithDebug(&dump),
+		WithBody(io.MultiReader(strings.NewReader("one-shot"))),
+		WithOK().ToJSON(&result),
+	)
+	require.NoError(t, err)
+	assert.Contains(t, dump.String(), "[non-rewindable body omitted]")
+}
+
+func Test_WithDebug_retry(t *testing.T) {
+	t.Parallel()
+
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, "expired")
+			return
+		}
+		_, _ = io.WriteString(w, "done")
+	}))
+	defer server.Close()
+
+	var dump strings.Builder
+	err := Post(server.URL,
+		WithDebug(&dump),
+		WithTextPlain("request body"),
+		WithRetryOnUnauthorized(func(context.Context, *http.Response) error { return nil }),
+		WithOK().ToDiscard(),
+	)
+	require.NoError(t, err)
+	require.Equal(t, 2, attempts)
+
+	out := dump.String()
+	markers := []string{
+		"--- rqx: attempt 1: request ---",
+		"--- rqx: attempt 1: response ---",
+		"--- rqx: attempt 2: request ---",
+		"--- rqx: attempt 2: response ---",
+	}
+	last := -1
+	for _, marker := range markers {
+		index := strings.Index(out, marker)
+		require.Greater(t, index, last, marker)
+		last = index
+	}
+	assert.Equal(t, 2, strings.Count(out, "request body"))
+	assert.Contains(t, out[:strings.Index(out, markers[2])], "401 Unauthorized")
+	assert.Contains(t, out[strings.Index(out, markers[3]):], "done")
+	assert.NotContains(t, out, "attempt 3")
+}
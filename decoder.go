@@ -4,19 +4,318 @@
 package rqx
 
 import (
+	"encoding"
+	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
+	"fmt"
 	"io"
+	"reflect"
+	"strconv"
+	"strings"
 )
 
 // Decoder reads from [io.Reader] and stores its decoded content
 // to the value pointed to by the given interface.
 type Decoder func(from io.Reader, to any) error
 
-func jsonDecoder(from io.Reader, to any) error {
-	return json.NewDecoder(from).Decode(to)
+// DecodeError is an error for the response body that cannot be decoded,
+// see [WithDecodeErrorContext].
+type DecodeError struct {
+	// BodyPrefix is the beginning of the body.
+	BodyPrefix string
+
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode failed: %v; body prefix: %q", e.Err, e.BodyPrefix)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+var _ error = (*DecodeError)(nil)
+
+// prefixRecorder records at most limit first bytes read from the reader.
+type prefixRecorder struct {
+	reader io.Reader
+	prefix []byte
+	limit  int
+}
+
+func (p *prefixRecorder) Read(b []byte) (int, error) {
+	n, err := p.reader.Read(b)
+	if rest := p.limit - len(p.prefix); rest > 0 {
+		p.prefix = append(p.prefix, b[:min(n, rest)]...)
+	}
+
+	return n, err
+}
+
+// decodeWithPrefix decodes the content of the given reader by the given
+// decoder. If the prefix limit is positive, the decoding error is wrapped
+// in [DecodeError] with at most that many first bytes of the content.
+func decodeWithPrefix(decoder Decoder, from io.Reader, to any, prefixLimit int) error {
+	if prefixLimit <= 0 {
+		return decoder(from, to)
+	}
+
+	recorder := &prefixRecorder{reader: from, limit: prefixLimit}
+
+	err := decoder(recorder, to)
+	if err == nil {
+		return nil
+	}
+
+	// The decoder may have stopped before reading the whole prefix.
+	_, _ = io.CopyN(io.Discard, recorder, int64(prefixLimit-len(recorder.prefix)))
+
+	return &DecodeError{BodyPrefix: string(recorder.prefix), Err: err}
+}
+
+func jsonDecoder(from io.Reader, to any, useNumber bool) error {
+	decoder := json.NewDecoder(from)
+	if useNumber {
+		decoder.UseNumber()
+	}
+
+	return decoder.Decode(to)
+}
+
+// jsonMapDecoder decodes the JSON content to the value pointed to by the given
+// *map[string]any, *[]any, or *any with the numbers as json.Number,
+// converting them to int64 where they fit if toInt64 is true.
+func jsonMapDecoder(from io.Reader, to any, toInt64 bool) error {
+	if err := jsonDecoder(from, to, true); err != nil {
+		return err
+	}
+
+	if !toInt64 {
+		return nil
+	}
+
+	switch result := to.(type) {
+	case *map[string]any:
+		numbersToInt64(*result)
+	case *[]any:
+		numbersToInt64(*result)
+	case *any:
+		*result = numbersToInt64(*result)
+	}
+
+	return nil
+}
+
+// numbersToInt64 replaces json.Number with int64 where it fits in the given
+// decoded JSON value recursively, modifying the maps and slices in place.
+func numbersToInt64(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+	case map[string]any:
+		for key, elem := range v {
+			v[key] = numbersToInt64(elem)
+		}
+	case []any:
+		for i, elem := range v {
+			v[i] = numbersToInt64(elem)
+		}
+	}
+
+	return value
 }
 
 func xmlDecoder(from io.Reader, to any) error {
 	return xml.NewDecoder(from).Decode(to)
 }
+
+const csvTag = "csv"
+
+var errCSVDest = errors.New("CSV destination must be a non-nil pointer to slice of structs")
+
+// CSVFieldError is an error for the struct field that cannot be populated
+// from the CSV record, see [OKStatuses.ToCSV].
+type CSVFieldError struct {
+	Line   int
+	Field  string
+	Column string
+	Err    error
+}
+
+func (e *CSVFieldError) Error() string {
+	return fmt.Sprintf(
+		"cannot populate field %s from column %s on line %d: %v",
+		e.Field, e.Column, e.Line, e.Err,
+	)
+}
+
+func (e *CSVFieldError) Unwrap() error {
+	return e.Err
+}
+
+var _ error = (*CSVFieldError)(nil)
+
+// csvDecoder reads CSV with a header row and stores the records
+// to the slice of structs, or pointers to structs, pointed to by the given
+// value. The zero delimiter means a comma.
+func csvDecoder(from io.Reader, to any, delimiter rune) error {
+	dest := reflect.ValueOf(to)
+	if dest.Kind() != reflect.Pointer || dest.IsNil() || dest.Elem().Kind() != reflect.Slice {
+		return errCSVDest
+	}
+
+	sliceType := dest.Elem().Type()
+	structType := sliceType.Elem()
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return errCSVDest
+	}
+
+	r := csv.NewReader(from)
+	if delimiter != 0 {
+		r.Comma = delimiter
+	}
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		dest.Elem().Set(reflect.MakeSlice(sliceType, 0, 0))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	fields := csvFields(structType, header)
+
+	rows := reflect.MakeSlice(sliceType, 0, 0)
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		row, err := csvRow(r, structType, header, fields, record)
+		if err != nil {
+			return err
+		}
+
+		if sliceType.Elem().Kind() != reflect.Pointer {
+			row = row.Elem()
+		}
+		rows = reflect.Append(rows, row)
+	}
+
+	dest.Elem().Set(rows)
+
+	return nil
+}
+
+// csvRow returns a pointer to the new struct of the given type populated
+// from the given record read by the given reader.
+func csvRow(
+	r *csv.Reader,
+	structType reflect.Type,
+	header []string,
+	fields []int,
+	record []string,
+) (reflect.Value, error) {
+	row := reflect.New(structType)
+	for i, value := range record {
+		if fields[i] < 0 {
+			continue
+		}
+
+		if err := setCSVField(row.Elem().Field(fields[i]), value); err != nil {
+			line, _ := r.FieldPos(i)
+			return reflect.Value{}, &CSVFieldError{
+				Line:   line,
+				Field:  structType.Field(fields[i]).Name,
+				Column: header[i],
+				Err:    err,
+			}
+		}
+	}
+
+	return row, nil
+}
+
+// csvFields returns the indices of the struct fields matched by the "csv" tag
+// to the columns of the given header, or -1 for the unmatched columns.
+func csvFields(structType reflect.Type, header []string) []int {
+	byName := make(map[string]int, structType.NumField())
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+
+		name, ok := field.Tag.Lookup(csvTag)
+		if !ok || name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		byName[name] = i
+	}
+
+	fields := make([]int, len(header))
+	for i, column := range header {
+		index, ok := byName[column]
+		if !ok {
+			index = -1
+		}
+		fields[i] = index
+	}
+
+	return fields
+}
+
+func setCSVField(field reflect.Value, value string) error {
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	if value == "" {
+		return nil
+	}
+
+	switch kind := field.Kind(); {
+	case kind == reflect.String:
+		field.SetString(value)
+	case kind == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case field.CanInt():
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case field.CanUint() && kind != reflect.Uintptr:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case field.CanFloat():
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type csvReport struct {
+	ID      int       `csv:"id"`
+	Name    string    `csv:"name"`
+	Score   float64   `csv:"score"`
+	Active  bool      `csv:"active"`
+	Created time.Time `csv:"created"`
+	Skipped string    `csv:"-"`
+}
+
+func Test_csvDecoder(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		body      string
+		delimiter rune
+		want      []csvReport
+	}{
+		{
+			name: "quoting and unknown columns",
+			body: "\ufeffid,name,extra,score,active,created\n" +
+				`1,"Doe, John",x,9.5,true,2025-03-01T12:00:00Z` + "\n" +
+				`2,"say ""hi""",y,,false,2025-03-01T12:00:00Z` + "\n",
+			want: []csvReport{
+				{ID: 1, Name: "Doe, John", Score: 9.5, Active: true, Created: created},
+				{ID: 2, Name: `say "hi"`, Created: created},
+			},
+		},
+		{
+			name:      "custom delimiter",
+			body:      "name;id\nAlice;3\n",
+			delimiter: ';',
+			want:      []csvReport{{ID: 3, Name: "Alice"}},
+		},
+		{
+			name: "header only",
+			body: "id,name\n",
+			want: []csvReport{},
+		},
+		{
+			name: "empty",
+			body: "",
+			want: []csvReport{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []csvReport
+			require.NoError(t, csvDecoder(strings.NewReader(tt.body), &got, tt.delimiter))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	var pointers []*csvReport
+	require.NoError(t, csvDecoder(strings.NewReader("id\n7\n"), &pointers, 0))
+	require.Len(t, pointers, 1)
+	assert.Equal(t, 7, pointers[0].ID)
+
+	var reports []csvReport
+	err := csvDecoder(strings.NewReader("id,name\n1,a\nx,b\n"), &reports, 0)
+	var fieldErr *CSVFieldError
+	require.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, 3, fieldErr.Line)
+	assert.Equal(t, "ID", fieldErr.Field)
+	assert.Equal(t, "id", fieldErr.Column)
+
+	require.ErrorIs(t, csvDecoder(strings.NewReader("id\n1\n"), reports, 0), errCSVDest)
+	require.ErrorIs(t, csvDecoder(strings.NewReader("id\n1\n"), &[]int{}, 0), errCSVDest)
+}
+
+func Test_ToCSV(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set(string(HeaderContentType), string(ContentCSV))
+		_, _ = w.Write([]byte("id\tname\n1\tAlice\n2\tBob\n"))
+	}))
+	defer server.Close()
+
+	var reports []csvReport
+	err := Get(server.URL, WithCSVDelimiter('\t'), WithOK().ToCSV(&reports))
+	require.NoError(t, err)
+	assert.Equal(t, []csvReport{{ID: 1, Name: "Alice"}, {ID: 2, Name: "Bob"}}, reports)
+
+	err = Get(server.URL, WithCSVDelimiter('"'), WithOK().ToCSV(&reports))
+	require.ErrorIs(t, err, errInvalidCSVDelimiter)
+}
+
+type numberError struct {
+	Data map[string]any `json:"data"`
+}
+
+func (e *numberError) Error() string {
+	return "number error"
+}
+
+func Test_WithJSONUseNumber(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("error") {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		_, _ = w.Write([]byte(`{"data": {"id": 9007199254740993}}`))
+	}))
+	defer server.Close()
+
+	var result map[string]any
+	err := Get(server.URL, WithOK().ToJSON(&result), WithJSONUseNumber())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": json.Number("9007199254740993")}, result["data"])
+
+	var field any
+	err = Get(server.URL, WithJSONUseNumber(), WithOK().ToJSONField(&field, "data.id"))
+	require.NoError(t, err)
+	assert.Equal(t, json.Number("9007199254740993"), field)
+
+	err = Get(server.URL+"?error",
+		WithJSONUseNumber(),
+		WithError[*numberError](http.StatusBadRequest).ToJSON(),
+	)
+	var numErr *numberError
+	require.ErrorAs(t, err, &numErr)
+	assert.Equal(t, json.Number("9007199254740993"), numErr.Data["id"])
+
+	err = Get(server.URL, WithOK().ToJSON(&result))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": float64(9007199254740993)}, result["data"])
+}
+
+func Test_WithDecodeErrorContext(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<html><body>Bad Gateway</body></html>`))
+	}))
+	defer server.Close()
+
+	var result map[string]any
+	err := Get(server.URL, WithOK().ToJSON(&result))
+	var syntaxErr *json.SyntaxError
+	require.ErrorAs(t, err, &syntaxErr)
+	var decodeErr *DecodeError
+	require.False(t, errors.As(err, &decodeErr))
+
+	err = Get(server.URL, WithDecodeErrorContext(12), WithOK().ToJSON(&result))
+	require.ErrorAs(t, err, &syntaxErr)
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, "<html><body>", decodeErr.BodyPrefix)
+	assert.Contains(t, err.Error(), `body prefix: "<html><body>"`)
+
+	err = Get(server.URL, WithDecodeErrorContext(1<<10), WithOK().ToJSON(&result))
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, "<html><body>Bad Gateway</body></html>", decodeErr.BodyPrefix)
+
+	err = Get(server.URL, WithDecodeErrorContext(0))
+	require.Error(t, err)
+}
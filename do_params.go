@@ -4,46 +4,404 @@
 package rqx
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"os"
+	"slices"
+	"time"
 
 	"github.com/tsayukov/optparams"
+	"golang.org/x/net/http/httpguts"
 )
 
 // doParams holds required and optional arguments of [Do].
 type doParams struct {
-	ctx          context.Context
-	client       *http.Client
-	urlBuilder   urlBuilder
-	headers      http.Header
-	body         io.Reader
-	handler      handler
-	errorWrapper ErrorWrapperFunc
+	ctx             context.Context
+	cancelCause     context.CancelCauseFunc
+	meta            map[any]any
+	values          []contextValue
+	client          *http.Client
+	transport       transportConfig
+	semaphore       *Semaphore
+	retryBudget     *RetryBudget
+	byteCounter     *ByteCounter
+	uploadProgress  *uploadProgress
+	tee             io.Writer
+	finalURL        *string
+	async           bool
+	asyncPool       *Semaphore
+	closeConn       bool
+	sameHost        bool
+	faults          FaultConfig
+	hedgeDelay      time.Duration
+	override        bool
+	customMethod    bool
+	urlBuilder      urlBuilder
+	urlValidator    urlValidator
+	headers         http.Header
+	trailers        []string
+	body            io.Reader
+	bodyWriter      BodyWriterFunc
+	bodyFile        *os.File
+	bodyLength      int64
+	chunked         bool
+	bodySource      string
+	bodyType        string
+	autoAccept      bool
+	bufferBody      bool
+	bodyOffset      int64
+	checksums       []bodyChecksum
+	handler         handler
+	errorWrapper    ErrorContextWrapperFunc
+	errorInfo       ErrorContext
+	started         time.Time
+	failOnError     bool
+	drainLimit      int64
+	strictClose     bool
+	onCloseError    func(err error)
+	bodyReadTimeout time.Duration
+	maxURLLength    optional[int]
+	maxHeaderBytes  optional[int]
+	debug           *debugger
+	traces          []*ClientTraceCallbacks
+	timings         *Timings
+	logger          *slog.Logger
+	latency         Observer
 }
 
+// hasBody reports whether the body content is set.
+func (params *doParams) hasBody() bool {
+	return params.body != nil || params.bodyWriter != nil
+}
+
+// overrideMethod returns [POST] for the [PUT], [PATCH], and [DELETE] methods
+// and sets the original method in the X-HTTP-Method-Override header if
+// required by [WithMethodOverride]. Otherwise, it returns the given method.
+func (params *doParams) overrideMethod(httpMethod HTTPMethod) HTTPMethod {
+	if !params.override {
+		return httpMethod
+	}
+
+	switch httpMethod {
+	case PUT, PATCH, DELETE:
+		params.headers[string(HeaderXHTTPMethodOverride)] = []string{string(httpMethod)}
+		return POST
+	default:
+		return httpMethod
+	}
+}
+
+// applyBodyContentType sets the Content-Type header to the content type
+// set by the body option unless the header is set explicitly, regardless
+// of the order of the options.
+func (params *doParams) applyBodyContentType() {
+	key := string(HeaderContentType)
+	if params.bodyType != "" && len(params.headers[key]) == 0 {
+		params.headers[key] = []string{params.bodyType}
+	}
+}
+
+// applyAutoAccept sets the Accept header to the media type decoded
+// by the handler for [OKStatuses] if [WithAutoAccept] is set, unless
+// the header is set explicitly, regardless of the order of the options.
+func (params *doParams) applyAutoAccept() {
+	key := string(HeaderAccept)
+	mediaType := params.handler.okMediaType
+	if params.autoAccept && mediaType != "" && len(params.headers[key]) == 0 {
+		params.headers[key] = []string{string(mediaType)}
+	}
+}
+
+// checkNoBody returns the [ErrBodyAlreadyExists] error naming the option
+// that has set the body and the given one, if the body is already set.
+func (params *doParams) checkNoBody(source string) error {
+	if !params.hasBody() {
+		return nil
+	}
+
+	return fmt.Errorf("%w: set by %s, cannot apply %s",
+		ErrBodyAlreadyExists, params.bodySource, source)
+}
+
+// bufferBodyContent reads the entire body into memory if required
+// by [WithBufferBody], closing the original body if it is [io.Closer].
+func (params *doParams) bufferBodyContent() error {
+	if !params.bufferBody || params.body == nil {
+		return nil
+	}
+
+	if _, ok := params.body.(*bytes.Reader); ok {
+		return nil
+	}
+
+	b, err := io.ReadAll(params.body)
+	if closer, ok := params.body.(io.Closer); ok {
+		err = errors.Join(err, closer.Close())
+	}
+	if err != nil {
+		return err
+	}
+
+	params.body = bytes.NewReader(b)
+
+	return nil
+}
+
+// closeBodyFile closes the file opened by [WithBodyFromFile], if any.
+// The file may have been already closed by [net/http.Client.Do].
+func (params *doParams) closeBodyFile() {
+	if params.bodyFile != nil {
+		_ = params.bodyFile.Close()
+		params.bodyFile = nil
+	}
+}
+
+// setBodyLength sets the length of the body opened by [WithBodyFromFile],
+// since [net/http.NewRequestWithContext] cannot infer it from [os.File],
+// or forces the chunked transfer encoding required by [WithChunkedBody].
+func (params *doParams) setBodyLength(req *http.Request) {
+	if params.chunked {
+		req.ContentLength = -1
+		req.TransferEncoding = []string{"chunked"}
+
+		return
+	}
+
+	if params.bodyFile == nil || params.bodyLength < 0 {
+		return
+	}
+
+	req.ContentLength = params.bodyLength
+	if params.bodyLength == 0 {
+		req.Body = http.NoBody
+	}
+}
+
+// saveBodyOffset saves the initial position of the body if it is [io.Seeker],
+// so the body can be rewound before each attempt.
+func (params *doParams) saveBodyOffset() error {
+	seeker, ok := params.body.(io.Seeker)
+	if !ok {
+		return nil
+	}
+
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	params.bodyOffset = offset
+
+	return nil
+}
+
+// rewindBody rewinds the body to the initial position if it is [io.Seeker],
+// so the body is replayed by the next attempt.
+func (params *doParams) rewindBody() error {
+	seeker, ok := params.body.(io.Seeker)
+	if !ok {
+		return nil
+	}
+
+	_, err := seeker.Seek(params.bodyOffset, io.SeekStart)
+
+	return err
+}
+
+// defaultDrainLimit is the default maximum number of bytes of the response
+// body that are read and discarded before closing it.
+const defaultDrainLimit = 256 << 10
+
 func newDoParams(opts ...Option) (*doParams, error) {
+	params, err := applyOptions(opts)
+	if err == nil {
+		err = params.prepare()
+	}
+
+	if err != nil {
+		// The request is never sent, so the body file would not be closed.
+		params.closeBodyFile()
+		return nil, err
+	}
+
+	return params, nil
+}
+
+// applyOptions applies the given options along with the defaults and prepares
+// the URL builder. It is shared by [Do] and [BuildURL], so both build the same
+// URL. The given slice is not modified.
+func applyOptions(opts []Option) (*doParams, error) {
 	params := &doParams{
-		headers: make(http.Header),
+		headers:    make(http.Header),
+		drainLimit: defaultDrainLimit,
 	}
 
-	opts = append(opts,
+	opts = append(slices.Clip(opts),
 		optparams.Default[doParams](&params.ctx, context.Background()),
 		optparams.Default[doParams](&params.client, http.DefaultClient),
-		optparams.Default[doParams](&params.errorWrapper, func(err error) error { return err }),
+		optparams.Default[doParams](&params.urlValidator.allowedSchemes, defaultAllowedSchemes),
 	)
 
 	if err := optparams.Apply(params, opts...); err != nil {
-		return nil, err
+		return params, err
 	}
 
-	if params.handler.rateLimitResponse != nil && params.body != nil {
-		_, ok := params.body.(io.Closer)
-		if ok { // if the body is io.Closer
-			return nil, errors.New("rate limit handler cannot be set if body is io.Closer")
-		}
+	return params, params.prepareURL()
+}
+
+// prepareURL checks the required parameters and prepares the URL builder.
+func (params *doParams) prepareURL() error {
+	// The defaults are expected to be set, but the request must never panic
+	// deep inside net/http if they are not.
+	if params.ctx == nil {
+		return errors.New("rqx: context is nil")
+	}
+	if params.client == nil {
+		return errors.New("rqx: client is nil")
 	}
 
-	return params, nil
+	params.applyDefaultLimits()
+
+	return params.urlBuilder.prepare()
+}
+
+// prepare prepares the parameters to send the request after the options
+// are applied, see [applyOptions].
+func (params *doParams) prepare() error {
+	params.applyBodyContentType()
+	params.applyAutoAccept()
+
+	if err := params.bufferBodyContent(); err != nil {
+		return err
+	}
+
+	if err := params.applyChecksums(); err != nil {
+		return err
+	}
+
+	if err := params.saveBodyOffset(); err != nil {
+		return err
+	}
+
+	if err := params.prepareClient(); err != nil {
+		return err
+	}
+
+	if err := params.checkReplayableBody(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ErrInvalidHTTPMethod is returned when the HTTP method given to [Do]
+// is unknown, unless [WithAllowCustomMethod] is set, or is not a valid token.
+var ErrInvalidHTTPMethod = errors.New("rqx: invalid HTTP method")
+
+// validateMethod returns the [ErrInvalidHTTPMethod] error if the given method
+// is not allowed, or the [ErrHedgeUnsafeMethod] error if the method cannot
+// be hedged as required by [WithHedge].
+func (params *doParams) validateMethod(httpMethod HTTPMethod) error {
+	valid := slices.Contains(knownHTTPMethods, httpMethod)
+	if params.customMethod {
+		valid = httpguts.ValidHeaderFieldName(string(httpMethod)) // a method is a token
+	}
+
+	if !valid {
+		return fmt.Errorf("%w %q", ErrInvalidHTTPMethod, string(httpMethod))
+	}
+
+	if params.hedgeDelay > 0 && !slices.Contains(safeHTTPMethods, httpMethod) {
+		return fmt.Errorf("%w, got %q", ErrHedgeUnsafeMethod, string(httpMethod))
+	}
+
+	return nil
+}
+
+// startAttempt updates the error context for the next attempt to send
+// the request and rewinds the request body, if needed.
+func (params *doParams) startAttempt() error {
+	params.errorInfo.Attempt++
+	params.errorInfo.StatusCode = 0
+
+	return params.rewindBody()
+}
+
+// wrapError wraps the given error by the wrapper set by [WithErrorWrapperFunc]
+// or alike, if any. Nil errors are returned as is.
+func (params *doParams) wrapError(err error) error {
+	if err == nil || params.errorWrapper == nil {
+		return err
+	}
+
+	info := params.errorInfo
+	info.Elapsed = time.Since(params.started)
+
+	return params.errorWrapper(err, info)
+}
+
+// buildURL returns the URL built from the given base URL by the URL options
+// and validated, see [Do] and [BuildURL].
+func (params *doParams) buildURL(base string) (string, error) {
+	if err := params.urlValidator.validateBase(base); err != nil {
+		return "", err
+	}
+
+	url := params.urlBuilder.build(base)
+
+	if err := params.urlValidator.validateURL(url); err != nil {
+		return "", err
+	}
+
+	return url, nil
+}
+
+// prepareClient replaces the client with its copy modified by the transport,
+// redirect, fault injection, and hedging options, if any.
+func (params *doParams) prepareClient() error {
+	client, err := cloneClient(params.client, params.transport)
+	if err != nil {
+		return err
+	}
+	params.client = client
+
+	if params.sameHost {
+		params.client = withSameHostRedirects(params.client)
+	}
+
+	if params.faults.Enabled {
+		params.client = withFaultInjection(params.client, params.faults)
+	}
+
+	if params.hedgeDelay > 0 {
+		params.client = withHedging(params.client, params.hedgeDelay)
+	}
+
+	return nil
+}
+
+// checkReplayableBody returns an error if the request may be retried
+// with the body that is closed by [net/http.Client.Do] after the first attempt.
+func (params *doParams) checkReplayableBody() error {
+	if params.body == nil {
+		return nil
+	}
+
+	if _, ok := params.body.(io.Closer); !ok {
+		return nil
+	}
+
+	if params.handler.rateLimitResponse != nil {
+		return errors.New("rate limit handler cannot be set if body is io.Closer")
+	}
+
+	if params.handler.unauthorizedResponse != nil {
+		return errors.New("unauthorized handler cannot be set if body is io.Closer")
+	}
+
+	return nil
 }
@@ -0,0 +1,310 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// downloadChunkAttempts is the maximum number of attempts to download
+// a single chunk by [DownloadRanged].
+const downloadChunkAttempts = 3
+
+var errInvalidContentRange = errors.New("invalid Content-Range header")
+
+// errRangeIgnored is returned by [downloadChunk] when the server responds
+// to the range request with the whole content, so [DownloadRanged] falls
+// back to downloading it sequentially.
+var errRangeIgnored = errors.New("range ignored by the server")
+
+// DownloadRanged downloads the content from the given URL to the given writer
+// in chunks of the given size, fetching at most parallelism chunks
+// concurrently. Each chunk is requested using [WithRange], written at its
+// offset, and retried individually on a transient failure, i.e.,
+// the network error reported by [IsRetryableNetErr], the truncated body,
+// or a 5xx status code. The content size is learned by requesting the first
+// byte. If the server does not support ranges, i.e., responds with
+// [net/http.StatusOK] to the first or any other range request, the content is
+// downloaded sequentially instead. The total number of written bytes is
+// verified against the content size.
+//
+// The given options are applied to each request, so they must not set
+// the body or the response handlers. The given slice is not modified.
+func DownloadRanged(
+	url string,
+	w io.WriterAt,
+	chunkSize int64,
+	parallelism int,
+	opts ...Option,
+) error {
+	if chunkSize <= 0 {
+		return fmt.Errorf("invalid chunk size: %d", chunkSize)
+	}
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var probe rangeProbe
+	if err := Get(url, append(slices.Clip(opts), WithRange(0, 0), probe.handle(w))...); err != nil {
+		return err
+	}
+
+	switch {
+	case !probe.partial:
+		return nil // downloaded sequentially by the probe
+	case probe.size < 0:
+		return downloadWhole(url, w, opts...)
+	}
+
+	written, err := downloadChunks(url, w, probe.size, chunkSize, parallelism, opts...)
+	if errors.Is(err, errRangeIgnored) {
+		return downloadWhole(url, w, opts...)
+	}
+	if err != nil {
+		return err
+	}
+
+	if written != probe.size {
+		return fmt.Errorf("written %d bytes, want %d: %w", written, probe.size, io.ErrUnexpectedEOF)
+	}
+
+	return nil
+}
+
+// downloadChunks downloads the content of the given size by the chunks
+// of the given size in parallel, writing each chunk at its offset.
+// It returns the number of bytes written.
+func downloadChunks(
+	url string,
+	w io.WriterAt,
+	size, chunkSize int64,
+	parallelism int,
+	opts ...Option,
+) (int64, error) {
+	chunks := make(chan [2]int64)
+	go func() {
+		defer close(chunks)
+		for start := int64(0); start < size; start += chunkSize {
+			chunks <- [2]int64{start, min(start+chunkSize, size) - 1}
+		}
+	}()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		errs    []error
+		written int64
+	)
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range chunks {
+				mu.Lock()
+				failed := len(errs) > 0
+				mu.Unlock()
+				if failed {
+					continue // drain the remaining chunks
+				}
+
+				n, err := downloadChunk(url, w, chunk[0], chunk[1], opts...)
+
+				mu.Lock()
+				written += n
+				if err != nil {
+					errs = append(errs, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return written, errors.Join(errs...)
+}
+
+// rangeProbe is the result of requesting the first byte by [DownloadRanged].
+type rangeProbe struct {
+	// partial reports whether the server supports ranges.
+	partial bool
+
+	// size is the content size, or -1 if it is unknown.
+	size int64
+}
+
+// handle sets a handler that learns the content size from the partial
+// response, or writes the whole content to the given writer otherwise.
+func (p *rangeProbe) handle(w io.WriterAt) Option {
+	return func(params *doParams) error {
+		params.handler.okResponse = func(resp *http.Response) (any, error) {
+			switch resp.StatusCode {
+			case http.StatusPartialContent, http.StatusRequestedRangeNotSatisfiable:
+				// The empty content is not satisfiable even for the first byte.
+				_, _, size, err := parseContentRange(resp.Header.Get(string(HeaderContentRange)))
+				p.partial, p.size = true, size
+				return p, err
+			case http.StatusOK:
+				return p, copyWhole(w, resp)
+			default:
+				return nil, nil
+			}
+		}
+
+		return nil
+	}
+}
+
+func downloadWhole(url string, w io.WriterAt, opts ...Option) error {
+	return Get(url, append(slices.Clip(opts), func(params *doParams) error {
+		params.handler.okResponse = func(resp *http.Response) (any, error) {
+			if resp.StatusCode != http.StatusOK {
+				return nil, nil
+			}
+
+			return w, copyWhole(w, resp)
+		}
+
+		return nil
+	})...)
+}
+
+// copyWhole copies the body to the given writer from the beginning,
+// verifying the number of bytes against the content length if it is known.
+func copyWhole(w io.WriterAt, resp *http.Response) error {
+	n, err := io.Copy(io.NewOffsetWriter(w, 0), resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.ContentLength >= 0 && n != resp.ContentLength {
+		return fmt.Errorf(
+			"written %d bytes, want %d: %w", n, resp.ContentLength, io.ErrUnexpectedEOF,
+		)
+	}
+
+	return nil
+}
+
+// downloadChunk downloads the bytes from start to end inclusive, retrying
+// on a transient failure, and returns the number of bytes written by the last
+// attempt. The response other than [net/http.StatusPartialContent] is not
+// read: [net/http.StatusOK] causes the errRangeIgnored error, and the others
+// cause the [UnexpectedStatusError] error.
+func downloadChunk(url string, w io.WriterAt, start, end int64, opts ...Option) (int64, error) {
+	var (
+		n   int64
+		err error
+	)
+
+	handle := func(params *doParams) error {
+		params.handler.okResponse = func(resp *http.Response) (any, error) {
+			switch resp.StatusCode {
+			case http.StatusOK:
+				return nil, errRangeIgnored
+			case http.StatusPartialContent:
+				// The requested chunk follows.
+			default:
+				return nil, &UnexpectedStatusError{
+					Expected: http.StatusPartialContent,
+					Actual:   resp.StatusCode,
+				}
+			}
+
+			from, to, _, err := parseContentRange(resp.Header.Get(string(HeaderContentRange)))
+			if err != nil {
+				return nil, err
+			}
+			if from != start || to != end {
+				return nil, fmt.Errorf("%w: got bytes %d-%d, want %d-%d",
+					errInvalidContentRange, from, to, start, end)
+			}
+
+			size := end - start + 1
+			n, err = io.Copy(io.NewOffsetWriter(w, start), io.LimitReader(resp.Body, size))
+			if err == nil && n != size {
+				err = io.ErrUnexpectedEOF
+			}
+
+			return w, err
+		}
+
+		return nil
+	}
+
+	for attempt := 0; attempt < downloadChunkAttempts; attempt++ {
+		n = 0
+		err = Get(url, append(slices.Clip(opts), WithRange(start, end), handle)...)
+		if !isTransientChunkErr(err) {
+			break
+		}
+	}
+
+	return n, err
+}
+
+// isTransientChunkErr reports whether the chunk download that failed
+// with the given error can be retried.
+func isTransientChunkErr(err error) bool {
+	var statusErr *UnexpectedStatusError
+	if errors.As(err, &statusErr) {
+		return StatusClassServerError.Contains(statusErr.Actual)
+	}
+
+	return IsRetryableNetErr(err) ||
+		errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, context.Canceled)
+}
+
+// parseContentRange parses the value of the Content-Range header, e.g.,
+// "bytes 0-99/1000", "bytes 0-99/*", or "bytes */1000". The unknown
+// positions and size are -1.
+func parseContentRange(value string) (start, end, size int64, _ error) {
+	rest, ok := strings.CutPrefix(value, "bytes ")
+	if !ok {
+		return 0, 0, 0, errInvalidContentRange
+	}
+
+	positions, total, ok := strings.Cut(rest, "/")
+	if !ok {
+		return 0, 0, 0, errInvalidContentRange
+	}
+
+	size = -1
+	if total != "*" {
+		var err error
+		if size, err = strconv.ParseInt(total, 10, 64); err != nil || size < 0 {
+			return 0, 0, 0, errInvalidContentRange
+		}
+	}
+
+	if positions == "*" {
+		if size < 0 {
+			return 0, 0, 0, errInvalidContentRange
+		}
+		return -1, -1, size, nil
+	}
+
+	first, last, ok := strings.Cut(positions, "-")
+	if !ok {
+		return 0, 0, 0, errInvalidContentRange
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, 0, errInvalidContentRange
+	}
+	end, err = strconv.ParseInt(last, 10, 64)
+	if err != nil || start < 0 || end < start || (size >= 0 && end >= size) {
+		return 0, 0, 0, errInvalidContentRange
+	}
+
+	return start, end, size, nil
+}
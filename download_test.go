@@ -0,0 +1,205 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type writerAtBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *writerAtBuffer) WriteAt(p []byte, off int64) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if end := int(off) + len(p); end > len(b.buf) {
+		b.buf = append(b.buf, make([]byte, end-len(b.buf))...)
+	}
+
+	return copy(b.buf[off:], p), nil
+}
+
+func Test_DownloadRanged(t *testing.T) {
+	t.Parallel()
+
+	content := []byte(strings.Repeat("0123456789abcdef", 64)) // 1KiB
+
+	t.Run("ranges", func(t *testing.T) {
+		t.Parallel()
+
+		var requests, failures atomic.Int32
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			// Fail the chunk at 100 once to check the retry.
+			if strings.HasPrefix(r.Header.Get(string(HeaderRange)), "bytes=100-") &&
+				failures.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+		}))
+		defer server.Close()
+
+		var out writerAtBuffer
+		require.NoError(t, DownloadRanged(server.URL, &out, 100, 4))
+		assert.Equal(t, content, out.buf)
+		assert.Equal(t, int32(1+11+1), requests.Load())
+	})
+
+	t.Run("no range support", func(t *testing.T) {
+		t.Parallel()
+
+		var requests atomic.Int32
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requests.Add(1)
+			_, _ = w.Write(content)
+		}))
+		defer server.Close()
+
+		var out writerAtBuffer
+		require.NoError(t, DownloadRanged(server.URL, &out, 100, 4))
+		assert.Equal(t, content, out.buf)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("range ignored after probe", func(t *testing.T) {
+		t.Parallel()
+
+		var requests atomic.Int32
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			if r.Header.Get(string(HeaderRange)) != "bytes=0-0" {
+				r.Header.Del(string(HeaderRange))
+			}
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+		}))
+		defer server.Close()
+
+		var out writerAtBuffer
+		require.NoError(t, DownloadRanged(server.URL, &out, 100, 1))
+		assert.Equal(t, content, out.buf)
+		assert.Equal(t, int32(1+1+1), requests.Load())
+	})
+
+	t.Run("permanent error", func(t *testing.T) {
+		t.Parallel()
+
+		var requests atomic.Int32
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			if r.Header.Get(string(HeaderRange)) != "bytes=0-0" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+		}))
+		defer server.Close()
+
+		var out writerAtBuffer
+		err := DownloadRanged(server.URL, &out, 100, 1)
+
+		var statusErr *UnexpectedStatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusForbidden, statusErr.Actual)
+		assert.Equal(t, int32(1+1), requests.Load())
+	})
+
+	t.Run("options with spare capacity", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+		}))
+		defer server.Close()
+
+		// The workers must not append to the shared backing array.
+		opts := make([]Option, 0, 16)
+		opts = append(opts, WithHeader("X-Client", "test"))
+
+		var out writerAtBuffer
+		require.NoError(t, DownloadRanged(server.URL, &out, 100, 4, opts...))
+		assert.Equal(t, content, out.buf)
+		assert.Len(t, opts, 1)
+	})
+
+	t.Run("empty content", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(nil))
+		}))
+		defer server.Close()
+
+		var out writerAtBuffer
+		require.NoError(t, DownloadRanged(server.URL, &out, 100, 4))
+		assert.Empty(t, out.buf)
+	})
+
+	t.Run("wrong range", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(string(HeaderRange)) != "bytes=0-0" {
+				r.Header.Set(string(HeaderRange), "bytes=0-9")
+			}
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+		}))
+		defer server.Close()
+
+		var out writerAtBuffer
+		err := DownloadRanged(server.URL, &out, 100, 2)
+		require.ErrorIs(t, err, errInvalidContentRange)
+	})
+
+	require.Error(t, DownloadRanged("http://localhost", &writerAtBuffer{}, 0, 1))
+}
+
+func Test_parseContentRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value   string
+		start   int64
+		end     int64
+		size    int64
+		wantErr bool
+	}{
+		{value: "bytes 0-99/1000", start: 0, end: 99, size: 1000},
+		{value: "bytes 0-99/*", start: 0, end: 99, size: -1},
+		{value: "bytes */1000", start: -1, end: -1, size: 1000},
+		{value: "bytes */*", wantErr: true},
+		{value: "bytes 10-5/1000", wantErr: true},
+		{value: "bytes 0-1000/1000", wantErr: true},
+		{value: "items 0-9/10", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		start, end, size, err := parseContentRange(tt.value)
+		if tt.wantErr {
+			assert.ErrorIs(t, err, errInvalidContentRange, tt.value)
+			continue
+		}
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, []int64{tt.start, tt.end, tt.size}, []int64{start, end, size}, tt.value)
+	}
+}
@@ -0,0 +1,22 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+)
+
+// Encoder writes the encoded value pointed to by the given interface
+// to [io.Writer].
+type Encoder func(to io.Writer, from any) error
+
+func jsonEncoder(to io.Writer, from any) error {
+	return json.NewEncoder(to).Encode(from)
+}
+
+func xmlEncoder(to io.Writer, from any) error {
+	return xml.NewEncoder(to).Encode(from)
+}
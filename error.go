@@ -5,10 +5,13 @@ package rqx
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // ErrorStatuses are HTTP error response status codes.
@@ -18,19 +21,26 @@ type ErrorStatuses[E error] responseStatuses
 // and store decoded [net/http.Response.Body] to the value pointed to by the error
 // returned by the handler.
 func (e ErrorStatuses[E]) To(decoder Decoder) Option {
+	return e.to(decoder, func() *E { return new(E) })
+}
+
+// to works like [ErrorStatuses.To], but decodes the body to the error pointed
+// to by the value returned by the given function for each response.
+func (e ErrorStatuses[E]) to(decoder Decoder, target func() *E) Option {
 	return func(params *doParams) error {
+		params.handler.hasErrorHandler = true
 		params.handler.errorResponses = append(params.handler.errorResponses,
 			func(resp *http.Response) error {
-				if !slices.Contains(e, resp.StatusCode) {
+				if !responseStatuses(e).contains(resp.StatusCode) {
 					return nil
 				}
 
-				var resultError E
-				if err := decoder(resp.Body, &resultError); err != nil {
+				resultError := target()
+				if err := params.handler.decode(decoder, resp.Body, resultError); err != nil {
 					return err
 				}
 
-				return resultError
+				return *resultError
 			},
 		)
 
@@ -42,7 +52,9 @@ func (e ErrorStatuses[E]) To(decoder Decoder) Option {
 // JSON-decoded [net/http.Response.Body] to the value pointed to by the error
 // returned by the handler.
 func (e ErrorStatuses[E]) ToJSON() Option {
-	return e.To(jsonDecoder)
+	return func(params *doParams) error {
+		return e.To(params.handler.decodeJSON)(params)
+	}
 }
 
 // ToXML sets a handler for [ErrorStatuses]. The handler reads and stores
@@ -54,15 +66,237 @@ func (e ErrorStatuses[E]) ToXML() Option {
 
 type ErrorWrapperFunc func(error) error
 
+// ErrorContext describes the request that caused the error,
+// see [WithErrorWrapperFunc].
+type ErrorContext struct {
+	// HTTPMethod is the HTTP method given to [Do].
+	HTTPMethod HTTPMethod
+
+	// URL is the URL of the request, or the URL given to [Do] if the error
+	// occurred before the URL is built.
+	URL string
+
+	// StatusCode is the HTTP status code of the response, or 0 if there is
+	// no response.
+	StatusCode int
+
+	// Attempt is the number of the attempt to send the request starting
+	// from 1, or 0 if the error occurred before the first attempt.
+	Attempt int
+
+	// Elapsed is the time elapsed since [Do] was called.
+	Elapsed time.Duration
+}
+
+// ErrorContextWrapperFunc wraps the error given the context of the request
+// that caused it, see [WithErrorWrapperFunc].
+type ErrorContextWrapperFunc func(err error, info ErrorContext) error
+
+// URLPart is a part of the URL that failed validation.
+type URLPart string
+
+const (
+	URLPartSyntax  URLPart = "syntax"
+	URLPartControl URLPart = "control character"
+	URLPartScheme  URLPart = "scheme"
+	URLPartHost    URLPart = "host"
+	URLPartLength  URLPart = "length"
+)
+
+// urlSnippetLimit is the maximum number of bytes of the URL in the message
+// of [InvalidURLError] for the URL that is too long.
+const urlSnippetLimit = 128
+
+// InvalidURLError is an error for the URL that failed validation before
+// sending the request.
+type InvalidURLError struct {
+	// URL is the offending URL.
+	URL string
+
+	// IsBase reports whether the base URL given to [Do] is invalid,
+	// see [WithBaseURLCheck].
+	IsBase bool
+
+	// Part is the offending part of the URL.
+	Part URLPart
+
+	// Err is the underlying error, if any.
+	Err error
+}
+
+func (e *InvalidURLError) Error() string {
+	kind := "URL"
+	if e.IsBase {
+		kind = "base URL"
+	}
+
+	url := strconv.Quote(e.URL)
+	if e.Part == URLPartLength && len(e.URL) > urlSnippetLimit {
+		url = strconv.Quote(e.URL[:urlSnippetLimit]) + "..."
+	}
+
+	if e.Err != nil {
+		return fmt.Sprintf("invalid %s %s: %s: %v", kind, url, e.Part, e.Err)
+	}
+
+	return fmt.Sprintf("invalid %s %s: %s", kind, url, e.Part)
+}
+
+func (e *InvalidURLError) Unwrap() error {
+	return e.Err
+}
+
+var _ error = (*InvalidURLError)(nil)
+
+// UnexpectedStatusError is an error for the response whose status code
+// differs from the expected one, see [WithExpectStatus].
+type UnexpectedStatusError struct {
+	Expected int
+	Actual   int
+}
+
+func (e *UnexpectedStatusError) Error() string {
+	return fmt.Sprintf("unexpected response status %d %s, expected %d %s",
+		e.Actual, http.StatusText(e.Actual), e.Expected, http.StatusText(e.Expected),
+	)
+}
+
+var _ error = (*UnexpectedStatusError)(nil)
+
+// UnexpectedContentTypeError is an error for the response whose media type
+// differs from the expected ones, see [WithExpectContentType].
+type UnexpectedContentTypeError struct {
+	// Expected are the expected media types, possibly with the wildcards.
+	Expected []ContentType
+
+	// Actual is the Content-Type header of the response as is.
+	Actual string
+}
+
+func (e *UnexpectedContentTypeError) Error() string {
+	expected := make([]string, len(e.Expected))
+	for i, mediaType := range e.Expected {
+		expected[i] = string(mediaType)
+	}
+	want := strings.Join(expected, " or ")
+
+	if e.Actual == "" {
+		return fmt.Sprintf("unexpected response without content type, expected %s", want)
+	}
+
+	return fmt.Sprintf("unexpected response content type %q, expected %s", e.Actual, want)
+}
+
+var _ error = (*UnexpectedContentTypeError)(nil)
+
+// UnexpectedHeaderError is an error for the response whose header does not
+// satisfy the predicate, see [WithExpectHeader].
+type UnexpectedHeaderError struct {
+	// Key is the checked header.
+	Key HeaderKey
+
+	// Actual is the first value of the header, or the empty string
+	// if the header is missing.
+	Actual string
+}
+
+func (e *UnexpectedHeaderError) Error() string {
+	if e.Actual == "" {
+		return fmt.Sprintf("unexpected response without header %s", e.Key)
+	}
+
+	return fmt.Sprintf("unexpected response header %s: %q", e.Key, e.Actual)
+}
+
+var _ error = (*UnexpectedHeaderError)(nil)
+
+// httpStatusSnippetLimit is the maximum number of bytes of the body
+// in [HTTPStatusError].
+const httpStatusSnippetLimit = 2 << 10
+
+// HTTPStatusError is an error for the response with the 4xx or 5xx status
+// code that did not match any handlers, see [WithFailOnErrorStatus].
+type HTTPStatusError struct {
+	// Status is the HTTP status code of the response.
+	Status int
+
+	// Snippet is at most 2 KiB of the response body.
+	Snippet string
+
+	// Header is the HTTP header of the response.
+	Header http.Header
+}
+
+func newHTTPStatusError(resp *http.Response) error {
+	snippet, err := io.ReadAll(io.LimitReader(resp.Body, httpStatusSnippetLimit))
+	if err != nil {
+		return err
+	}
+
+	return &HTTPStatusError{
+		Status:  resp.StatusCode,
+		Snippet: string(snippet),
+		Header:  resp.Header.Clone(),
+	}
+}
+
+func (e *HTTPStatusError) Error() string {
+	if e.Snippet == "" {
+		return fmt.Sprintf("HTTP status %d %s", e.Status, http.StatusText(e.Status))
+	}
+
+	return fmt.Sprintf("HTTP status %d %s: %s", e.Status, http.StatusText(e.Status), e.Snippet)
+}
+
+// StatusCode returns the HTTP status code of the response.
+func (e *HTTPStatusError) StatusCode() int {
+	return e.Status
+}
+
+// Is reports whether the target is [ErrUnhandledStatus] with the same status
+// code as the response.
+func (e *HTTPStatusError) Is(target error) bool {
+	status, ok := target.(unhandledStatusError)
+	return ok && int(status) == e.Status
+}
+
+var _ error = (*HTTPStatusError)(nil)
+
+// StatusSentinelError is an error for the response whose status code
+// is mapped to the sentinel error, see [WithErrorIs].
+type StatusSentinelError struct {
+	// Status is the HTTP status code of the response.
+	Status int
+
+	// Err is the sentinel error given to [WithErrorIs].
+	Err error
+}
+
+func (e *StatusSentinelError) Error() string {
+	return fmt.Sprintf("HTTP status %d %s: %v", e.Status, http.StatusText(e.Status), e.Err)
+}
+
+// StatusCode returns the HTTP status code of the response.
+func (e *StatusSentinelError) StatusCode() int {
+	return e.Status
+}
+
+func (e *StatusSentinelError) Unwrap() error {
+	return e.Err
+}
+
+var _ error = (*StatusSentinelError)(nil)
+
 // UnhandledResponseError is an error for the response that did not match
 // any handlers.
 type UnhandledResponseError struct {
 	status  int
 	headers http.Header
 	body    *bytes.Buffer
+	decoded any
 }
 
-func newUnhandledResponse(resp *http.Response) error {
+func newUnhandledResponse(resp *http.Response, h *handler) error {
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return err
@@ -72,14 +306,92 @@ func newUnhandledResponse(resp *http.Response) error {
 		status:  resp.StatusCode,
 		headers: resp.Header.Clone(),
 		body:    bytes.NewBuffer(body),
+		decoded: h.decodeUnhandled(body),
 	}
 }
 
 func (u *UnhandledResponseError) Error() string {
+	if u.decoded != nil {
+		return fmt.Sprintf(
+			"unhandled response with status %d:\n\theader: %#v\n\tbody: %+v",
+			u.status, u.headers, u.decoded,
+		)
+	}
+
 	return fmt.Sprintf(
 		"unhandled response with status %d:\n\theader: %#v\n\tbody: %s",
 		u.status, u.headers, u.body.String(),
 	)
 }
 
+// Is reports whether the target is [ErrUnhandledStatus] with the same status
+// code as the unhandled response.
+func (u *UnhandledResponseError) Is(target error) bool {
+	status, ok := target.(unhandledStatusError)
+	return ok && int(status) == u.status
+}
+
+// StatusCode returns the HTTP status code of the unhandled response.
+func (u *UnhandledResponseError) StatusCode() int {
+	return u.status
+}
+
+// Header returns the HTTP header of the unhandled response.
+func (u *UnhandledResponseError) Header() http.Header {
+	return u.headers
+}
+
+// Body returns a copy of the body of the unhandled response.
+func (u *UnhandledResponseError) Body() []byte {
+	return bytes.Clone(u.body.Bytes())
+}
+
+// Decoded returns the body of the unhandled response decoded by the decoder
+// set by [WithUnhandledDecoder], or nil if it is not set or decoding fails.
+func (u *UnhandledResponseError) Decoded() any {
+	return u.decoded
+}
+
 var _ error = (*UnhandledResponseError)(nil)
+
+// unhandledStatusError is the target for [errors.Is] returned
+// by [ErrUnhandledStatus].
+type unhandledStatusError int
+
+func (s unhandledStatusError) Error() string {
+	return fmt.Sprintf("unhandled response with status %d", int(s))
+}
+
+// ErrUnhandledStatus returns the target for [errors.Is] that matches
+// [UnhandledResponseError] and [HTTPStatusError] with the given status code:
+//
+//	if errors.Is(err, rqx.ErrUnhandledStatus(http.StatusNotFound)) {
+//		// ...
+//	}
+func ErrUnhandledStatus(status int) error {
+	return unhandledStatusError(status)
+}
+
+// StatusCoder is implemented by errors carrying the HTTP status code
+// of the response, e.g., [UnhandledResponseError] and [HTTPStatusError].
+// The errors returned by the handlers added by [WithError] may implement it
+// as well.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+var (
+	_ StatusCoder = (*UnhandledResponseError)(nil)
+	_ StatusCoder = (*HTTPStatusError)(nil)
+)
+
+// StatusCodeFromError returns the HTTP status code carried by the first error
+// in the error chain implementing [StatusCoder], if any.
+func StatusCodeFromError(err error) (int, bool) {
+	var coder StatusCoder
+	if errors.As(err, &coder) {
+		return coder.StatusCode(), true
+	}
+
+	return 0, false
+}
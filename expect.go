@@ -0,0 +1,55 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"errors"
+)
+
+// ErrAmbiguousHandlers is returned by [DoExpect] when the given options
+// already set the handlers for the successful or error HTTP responses.
+var ErrAmbiguousHandlers = errors.New(
+	"DoExpect cannot be used with the options setting OK or error handlers",
+)
+
+// DoExpect sends an HTTP request given [HTTPMethod], URL, and optional
+// parameters, see [Do]. It returns the JSON-decoded body of the response
+// with the [net/http.StatusOK] status code as T, or the JSON-decoded body
+// of the response with one of the given error status codes as the E error,
+// which can be checked by [errors.As].
+//
+// The options must not set the handlers by [WithOK], [WithError], and alike,
+// otherwise it causes the [ErrAmbiguousHandlers] error.
+func DoExpect[T any, E error](
+	httpMethod HTTPMethod,
+	url string,
+	errorStatuses []int,
+	opts ...Option,
+) (T, error) {
+	var result T
+
+	all := make([]Option, 0, len(opts)+3)
+	all = append(all, opts...)
+	all = append(all,
+		func(params *doParams) error {
+			if params.handler.okResponse != nil || params.handler.hasErrorHandler {
+				return ErrAmbiguousHandlers
+			}
+
+			return nil
+		},
+		WithOK().ToJSON(&result),
+	)
+
+	if len(errorStatuses) > 0 {
+		all = append(all, WithError[E](errorStatuses[0], errorStatuses[1:]...).ToJSON())
+	}
+
+	if err := Do(httpMethod, url, all...); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return result, nil
+}
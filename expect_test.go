@@ -0,0 +1,45 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DoExpect(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ok" {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(apiError{Message: "not found"})
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]int{"id": 42})
+	}))
+	defer server.Close()
+
+	type result struct {
+		ID int `json:"id"`
+	}
+
+	got, err := DoExpect[result, *apiError](GET, server.URL+"/ok", []int{http.StatusNotFound})
+	require.NoError(t, err)
+	assert.Equal(t, 42, got.ID)
+
+	_, err = DoExpect[result, *apiError](GET, server.URL+"/missing", []int{http.StatusNotFound})
+	var apiErr *apiError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "not found", apiErr.Message)
+
+	_, err = DoExpect[result, *apiError](GET, server.URL+"/ok", nil, WithOK().ToDiscard())
+	require.ErrorIs(t, err, ErrAmbiguousHandlers)
+}
@@ -0,0 +1,136 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrInjectedFault is the default transport error injected by
+// [WithFaultInjection].
+var ErrInjectedFault = errors.New("injected fault")
+
+// FaultConfig configures the faults injected by [WithFaultInjection].
+// The rates are probabilities from 0 to 1 drawn independently
+// for each attempt to send the request.
+type FaultConfig struct {
+	// Enabled must be set explicitly for the faults to be injected,
+	// so the option can stay at the call sites and be switched on in tests.
+	Enabled bool
+
+	// Rand is the source of the random numbers, e.g., seeded to make tests
+	// reproducible. If it is nil, the global source is used. Since the config
+	// is usually shared by concurrent requests, Rand is used under a lock.
+	Rand *rand.Rand
+
+	// LatencyRate is the probability of delaying the attempt by Latency
+	// before any other fault.
+	LatencyRate float64
+	Latency     time.Duration
+
+	// ErrorRate is the probability of failing the attempt with Err,
+	// or [ErrInjectedFault] if Err is nil, as if the transport failed.
+	ErrorRate float64
+	Err       error
+
+	// StatusRate is the probability of skipping the real request
+	// and passing the synthetic response with Status and an empty body
+	// to the handlers.
+	StatusRate float64
+	Status     int
+}
+
+// faultTransport injects the faults configured by [FaultConfig]
+// before calling the base transport.
+type faultTransport struct {
+	base   http.RoundTripper
+	config FaultConfig
+}
+
+// faultRandMu guards [FaultConfig.Rand], since [math/rand.Rand] is not safe
+// for concurrent use, and the same config, e.g., stored in a variable,
+// is used by all requests.
+var faultRandMu sync.Mutex
+
+// withFaultInjection returns a shallow copy of the given client whose
+// transport injects the configured faults.
+func withFaultInjection(c *http.Client, config FaultConfig) *http.Client {
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	clone := *c
+	clone.Transport = &faultTransport{base: base, config: config}
+
+	return &clone
+}
+
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.happens(t.config.LatencyRate) {
+		timer := time.NewTimer(t.config.Latency)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			closeRequestBody(req)
+			return nil, req.Context().Err()
+		}
+	}
+
+	if t.happens(t.config.ErrorRate) {
+		closeRequestBody(req)
+
+		if t.config.Err != nil {
+			return nil, t.config.Err
+		}
+		return nil, ErrInjectedFault
+	}
+
+	if t.happens(t.config.StatusRate) {
+		closeRequestBody(req)
+
+		return &http.Response{
+			Status:     fmt.Sprintf("%d %s", t.config.Status, http.StatusText(t.config.Status)),
+			StatusCode: t.config.Status,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// happens reports whether the event with the given probability happens.
+func (t *faultTransport) happens(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	if t.config.Rand == nil {
+		return rand.Float64() < rate //nolint:gosec // Faults are not security-sensitive
+	}
+
+	faultRandMu.Lock()
+	defer faultRandMu.Unlock()
+
+	return t.config.Rand.Float64() < rate
+}
+
+// closeRequestBody closes the body of the request that is not sent,
+// as [net/http.RoundTripper] must.
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
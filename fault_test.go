@@ -0,0 +1,130 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithFaultInjection(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		hits.Add(1)
+	}))
+	defer server.Close()
+
+	err := Get(server.URL,
+		WithFaultInjection(FaultConfig{ErrorRate: 1}),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), hits.Load())
+
+	err = Get(server.URL,
+		WithFaultInjection(FaultConfig{Enabled: true, ErrorRate: 1}),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.ErrorIs(t, err, ErrInjectedFault)
+	assert.Equal(t, int32(1), hits.Load())
+
+	err = Get(server.URL,
+		WithFaultInjection(FaultConfig{
+			Enabled:    true,
+			StatusRate: 1,
+			Status:     http.StatusServiceUnavailable,
+		}),
+		WithExpectStatus(http.StatusOK),
+	)
+	var statusErr *UnexpectedStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusServiceUnavailable, statusErr.Actual)
+	assert.Equal(t, int32(1), hits.Load())
+
+	start := time.Now()
+	err = Get(server.URL,
+		WithFaultInjection(FaultConfig{
+			Enabled:     true,
+			LatencyRate: 1,
+			Latency:     20 * time.Millisecond,
+		}),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Equal(t, int32(2), hits.Load())
+}
+
+func Test_WithFaultInjection_seeded(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	attempts := func(seed int64) int {
+		var n int
+		random := rand.New(rand.NewSource(seed)) //nolint:gosec // Seeded for reproducibility
+
+		err := Get(server.URL,
+			WithFaultInjection(FaultConfig{
+				Enabled:    true,
+				Rand:       random,
+				StatusRate: 0.7,
+				Status:     http.StatusTooManyRequests,
+			}),
+			WithHandlerBeforeResponse(func(*http.Request) error {
+				n++
+				return nil
+			}),
+			WithRateLimit(http.StatusTooManyRequests).Cooldown(
+				func(context.Context, *http.Response) error { return nil },
+			),
+			WithExpectStatus(http.StatusOK),
+		)
+		require.NoError(t, err)
+
+		return n
+	}
+
+	for seed := int64(0); seed < 5; seed++ {
+		assert.Equal(t, attempts(seed), attempts(seed))
+	}
+}
+
+func Test_WithFaultInjection_sharedRand(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	config := FaultConfig{
+		Enabled:    true,
+		Rand:       rand.New(rand.NewSource(1)), //nolint:gosec // Seeded for reproducibility
+		ErrorRate:  0.5,
+		StatusRate: 0.5,
+		Status:     http.StatusServiceUnavailable,
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = Get(server.URL, WithFaultInjection(config))
+		}()
+	}
+	wg.Wait()
+}
@@ -4,19 +4,54 @@
 package rqx
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"net/http"
+	"slices"
 )
 
 type (
 	handler struct {
 		beforeResponse []BeforeResponseHandler
 		afterResponse  []AfterResponseHandler
+		onStatus       []statusHandler
 
 		okResponse     okResponseHandler
+		okMediaType    ContentType
+		bodyValidators []BodyValidator
+		csvDelimiter   rune
+		jsonUseNumber  bool
+		jsonInt64      bool
+		decodePrefix   int
 		errorResponses []errorResponseHandler
 
+		// hasErrorHandler reports whether any handler added by [WithError]
+		// or alike is among errorResponses.
+		hasErrorHandler bool
+
+		// expectedStatus is the status code set by [WithExpectStatus], if any.
+		// Any other status code not handled otherwise causes
+		// the [UnexpectedStatusError] error.
+		expectedStatus int
+
+		// okResult stores the result of okResponse, if not nil, see [DoAll].
+		okResult *any
+
 		rateLimitResponse RateLimitHandler
+
+		unauthorizedResponse UnauthorizedHandler
+
+		// unauthorizedRetried reports whether the request has been retried
+		// after unauthorizedResponse.
+		unauthorizedRetried bool
+
+		trailerResponse []TrailerHandler
+
+		// unhandledDecoder decodes the body of the unhandled response
+		// to the value created by unhandledResult.
+		unhandledDecoder Decoder
+		unhandledResult  func() any
 	}
 
 	// BeforeResponseHandler handles [net/http.Request] right before the sending
@@ -27,7 +62,16 @@ type (
 	// receiving non-nil [net/http.Response].
 	AfterResponseHandler func(*http.Response) error
 
-	responseStatuses []int
+	// statusHandler handles [net/http.Response] whose HTTP status code
+	// matches the given one without consuming [net/http.Response.Body].
+	statusHandler struct {
+		status int
+		handle AfterResponseHandler
+	}
+
+	// BodyValidator validates [net/http.Response.Body] of the successful
+	// HTTP response before decoding it.
+	BodyValidator func(body io.Reader) error
 
 	// okResponseHandler handles [net/http.Response] whose HTTP status code
 	// matches one of [OKStatuses].
@@ -37,9 +81,18 @@ type (
 	// matches one of [ErrorStatuses].
 	errorResponseHandler func(*http.Response) error
 
+	// TrailerHandler handles [net/http.Response.Trailer] after
+	// [net/http.Response.Body] is fully read.
+	TrailerHandler func(trailer http.Header) error
+
 	// RateLimitHandler handles [net/http.Response] whose HTTP status code
 	// matches one of [RateLimitStatuses].
 	RateLimitHandler func(ctx context.Context, resp *http.Response) error
+
+	// UnauthorizedHandler handles [net/http.Response] with
+	// the [net/http.StatusUnauthorized] status code before retrying
+	// the request, see [WithRetryOnUnauthorized].
+	UnauthorizedHandler func(ctx context.Context, resp *http.Response) error
 )
 
 func (h *handler) applyBefore(req *http.Request) error {
@@ -62,6 +115,79 @@ func (h *handler) applyAfter(resp *http.Response) error {
 	return nil
 }
 
+// applyOnStatus calls the status handlers, each with a copy of the response
+// whose body can be read without consuming [net/http.Response.Body].
+// The body is buffered lazily up to [replayLimit] bytes, as in
+// [handler.matchError], so a handler that does not read the body does not
+// load it into memory.
+// It is called after [handler.applyAfter] and before [handler.matchOK]
+// and [handler.matchError], so the status handlers are not terminal.
+func (h *handler) applyOnStatus(resp *http.Response) error {
+	if !slices.ContainsFunc(h.onStatus, func(sh statusHandler) bool {
+		return sh.status == resp.StatusCode
+	}) {
+		return nil
+	}
+
+	body := newReplayBody(resp.Body, replayLimit)
+	defer func() { resp.Body = body.view(true) }()
+
+	for _, sh := range h.onStatus {
+		if sh.status != resp.StatusCode {
+			continue
+		}
+
+		view := *resp
+		view.Body = body.view(false)
+
+		if err := sh.handle(&view); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateBody calls the body validators, each with a copy of the response
+// body, so that the body can still be decoded afterwards.
+func (h *handler) validateBody(resp *http.Response) error {
+	for _, validate := range h.bodyValidators {
+		peeked, err := peekResponse(resp)
+		if err != nil {
+			return err
+		}
+
+		if err := validate(peeked.Body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readCloser combines the separately given [io.Reader] and [io.Closer].
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// peekResponse reads the whole body of the given response and replaces it
+// with the buffered one that still closes the original body. It returns
+// a shallow copy of the response with its own reader of the same content.
+func peekResponse(resp *http.Response) (*http.Response, error) {
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = readCloser{Reader: bytes.NewReader(content), Closer: resp.Body}
+
+	peeked := *resp
+	peeked.Body = io.NopCloser(bytes.NewReader(content))
+
+	return &peeked, nil
+}
+
 func (h *handler) matchOK(resp *http.Response) (match bool, _ error) {
 	if h.okResponse == nil {
 		return false, nil
@@ -69,19 +195,104 @@ func (h *handler) matchOK(resp *http.Response) (match bool, _ error) {
 
 	result, err := h.okResponse(resp)
 	if result != nil || err != nil {
+		if _, ok := result.(discarded); !ok && h.okResult != nil && err == nil {
+			*h.okResult = result
+		}
+
 		return true, err
 	}
 
 	return false, nil
 }
 
+// retryUnauthorized calls unauthorizedResponse on the first response with
+// the [net/http.StatusUnauthorized] status code and reports whether
+// the request must be retried.
+func (h *handler) retryUnauthorized(ctx context.Context, resp *http.Response) (bool, error) {
+	if h.unauthorizedResponse == nil || h.unauthorizedRetried ||
+		resp.StatusCode != http.StatusUnauthorized {
+		return false, nil
+	}
+
+	h.unauthorizedRetried = true
+
+	if err := h.unauthorizedResponse(ctx, resp); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// matchError calls the error handlers, each with a shallow copy
+// of the response whose body is read from the start, so a handler may inspect
+// the body even if it does not match the response. The body is buffered
+// lazily up to [replayLimit] bytes, and the response gets a fresh reader
+// of the body for the following handling.
 func (h *handler) matchError(resp *http.Response) error {
+	if len(h.errorResponses) == 0 {
+		return nil
+	}
+
+	body := newReplayBody(resp.Body, replayLimit)
+	defer func() { resp.Body = body.view(true) }()
+
 	for _, errorHandler := range h.errorResponses {
-		err := errorHandler(resp)
-		if err != nil {
+		view := *resp
+		view.Body = body.view(false)
+
+		if err := errorHandler(&view); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// applyTrailer reads the rest of the response body, so the trailers are
+// populated, and calls the trailer handlers.
+func (h *handler) applyTrailer(resp *http.Response) error {
+	if len(h.trailerResponse) == 0 {
+		return nil
+	}
+
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return err
+	}
+
+	for _, fn := range h.trailerResponse {
+		if err := fn(resp.Trailer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decode decodes the response body by the given decoder, adding the body
+// prefix to the decoding error if [WithDecodeErrorContext] is set.
+func (h *handler) decode(decoder Decoder, body io.Reader, to any) error {
+	return decodeWithPrefix(decoder, body, to, h.decodePrefix)
+}
+
+// decodeJSON decodes the JSON content of the given reader to the given value,
+// as json.Number for numbers in interface values if [WithJSONUseNumber]
+// is set. It reads the setting when called, so the order of the options
+// does not matter.
+func (h *handler) decodeJSON(from io.Reader, to any) error {
+	return jsonDecoder(from, to, h.jsonUseNumber)
+}
+
+// decodeUnhandled returns the given body of the unhandled response decoded
+// by unhandledDecoder, or nil if the decoder is not set or decoding fails.
+func (h *handler) decodeUnhandled(body []byte) any {
+	if h.unhandledDecoder == nil || len(body) == 0 {
+		return nil
+	}
+
+	result := h.unhandledResult()
+	if err := h.unhandledDecoder(bytes.NewReader(body), result); err != nil {
+		return nil
+	}
+
+	return result
+}
@@ -4,9 +4,36 @@
 package rqx
 
 import (
+	"errors"
+	"fmt"
 	"net/textproto"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
 )
 
+// ErrInvalidHeaderKey is returned when the header key is not a valid field
+// name token of RFC 9110, e.g., it contains spaces or colons.
+var ErrInvalidHeaderKey = errors.New("invalid header key")
+
+// NewHeaderKey returns [HeaderKey] canonicalized from the given string, e.g.,
+// "x-request-id" becomes "X-Request-Id". If the string is not a valid field
+// name token of RFC 9110, it returns the [ErrInvalidHeaderKey] error.
+func NewHeaderKey(s string) (HeaderKey, error) {
+	if !httpguts.ValidHeaderFieldName(s) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidHeaderKey, s)
+	}
+
+	return HeaderKey(textproto.CanonicalMIMEHeaderKey(s)), nil
+}
+
+// Equal reports whether the header key equals the given header name
+// case-insensitively.
+func (k HeaderKey) Equal(other string) bool {
+	return strings.EqualFold(string(k), other)
+}
+
 type HeaderAppendMode bool
 
 // HeaderAppendModeON makes [net/http.Header] use [net/http.Header.Add]
@@ -20,11 +47,18 @@ type withHeaderOptions struct {
 
 func withHeader(key HeaderKey, value string, options withHeaderOptions) Option {
 	canonicalKey := string(key)
+
+	var keyErr error
 	if !options.isKeyCanonicalized {
-		canonicalKey = textproto.CanonicalMIMEHeaderKey(canonicalKey)
+		key, keyErr = NewHeaderKey(canonicalKey)
+		canonicalKey = string(key)
 	}
 
 	return func(params *doParams) error {
+		if keyErr != nil {
+			return keyErr
+		}
+
 		if options.doesAddValueToEnd {
 			params.headers[canonicalKey] = append(params.headers[canonicalKey], value)
 		} else {
@@ -34,3 +68,41 @@ func withHeader(key HeaderKey, value string, options withHeaderOptions) Option {
 		return nil
 	}
 }
+
+// AcceptValues returns the value of the HTTP Accept header listing the given
+// content types in order of preference: the first one has the implicit
+// quality 1, and each next one has the quality lower by 0.1, but at least 0.1,
+// e.g., "application/json, application/xml;q=0.9". Use it with [WithAccept].
+func AcceptValues(types ...ContentType) string {
+	var value strings.Builder
+
+	for i, t := range types {
+		if i > 0 {
+			value.WriteString(", ")
+		}
+
+		value.WriteString(string(t))
+
+		if i > 0 {
+			tenths := max(10-i, 1)
+			value.WriteString(";q=0.")
+			value.WriteString(strconv.Itoa(tenths))
+		}
+	}
+
+	return value.String()
+}
+
+// CacheControl returns the value of the HTTP Cache-Control header joining
+// the given directives, e.g., "no-cache" and "max-age=0", and skipping
+// empty ones.
+func CacheControl(directives ...string) string {
+	nonEmpty := make([]string, 0, len(directives))
+	for _, directive := range directives {
+		if directive = strings.TrimSpace(directive); directive != "" {
+			nonEmpty = append(nonEmpty, directive)
+		}
+	}
+
+	return strings.Join(nonEmpty, ", ")
+}
@@ -0,0 +1,63 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewHeaderKey(t *testing.T) {
+	t.Parallel()
+
+	key, err := NewHeaderKey("x-request-id")
+	require.NoError(t, err)
+	assert.Equal(t, HeaderKey("X-Request-Id"), key)
+
+	for _, invalid := range []string{"", "X Bad Key", "X-Key:", "X-Ключ", "X-Key\r\n"} {
+		_, err = NewHeaderKey(invalid)
+		require.ErrorIs(t, err, ErrInvalidHeaderKey, invalid)
+	}
+
+	assert.True(t, HeaderContentType.Equal("content-TYPE"))
+	assert.False(t, HeaderContentType.Equal("Content-Length"))
+}
+
+func Test_WithHeader_invalidKey(t *testing.T) {
+	t.Parallel()
+
+	params, err := newDoParams(WithHeader("x-trace", "1", HeaderAppendModeON))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, params.headers["X-Trace"])
+
+	_, err = newDoParams(WithHeader("X Bad Key", "v"))
+	require.ErrorIs(t, err, ErrInvalidHeaderKey)
+	require.ErrorContains(t, err, `"X Bad Key"`)
+}
+
+func Test_AcceptValues(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", AcceptValues())
+	assert.Equal(t, "application/json", AcceptValues(ContentJSON))
+	assert.Equal(t,
+		"application/json, application/xml;q=0.9, text/plain;q=0.8",
+		AcceptValues(ContentJSON, ContentXML, ContentTextPlain),
+	)
+
+	many := make([]ContentType, 12)
+	for i := range many {
+		many[i] = ContentTextPlain
+	}
+	assert.Contains(t, AcceptValues(many...), "text/plain;q=0.1, text/plain;q=0.1")
+}
+
+func Test_CacheControl(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", CacheControl())
+	assert.Equal(t, "no-cache, max-age=0", CacheControl("no-cache", " ", "max-age=0 "))
+}
@@ -0,0 +1,164 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// ErrHedgeUnsafeMethod is returned when [WithHedge] is used with the HTTP
+// method that is not safe, since the hedged request may be executed twice.
+var ErrHedgeUnsafeMethod = errors.New("hedged requests require a safe HTTP method")
+
+// safeHTTPMethods are the methods that do not change the state of the server,
+// so they can be hedged.
+var safeHTTPMethods = []HTTPMethod{GET, "HEAD", OPTIONS, "TRACE", PROPFIND}
+
+// hedgeTransport sends a hedged request if the base transport has not
+// responded within the delay, see [WithHedge].
+type hedgeTransport struct {
+	base  http.RoundTripper
+	delay time.Duration
+}
+
+// withHedging returns a shallow copy of the given client whose transport
+// hedges the requests after the given delay.
+func withHedging(c *http.Client, delay time.Duration) *http.Client {
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	clone := *c
+	clone.Transport = &hedgeTransport{base: base, delay: delay}
+
+	return &clone
+}
+
+// hedgeResult is the result of one of the hedged attempts.
+type hedgeResult struct {
+	index int
+	resp  *http.Response
+	err   error
+}
+
+func (t *hedgeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !slices.Contains(safeHTTPMethods, HTTPMethod(req.Method)) {
+		return t.base.RoundTrip(req)
+	}
+
+	results := make(chan hedgeResult, 2)
+	var cancels []context.CancelFunc
+	launch := func(r *http.Request) {
+		ctx, cancel := context.WithCancel(r.Context())
+		cancels = append(cancels, cancel)
+
+		index := len(cancels) - 1
+		go func() {
+			resp, err := t.base.RoundTrip(r.WithContext(ctx))
+			results <- hedgeResult{index: index, resp: resp, err: err}
+		}()
+	}
+
+	launch(req)
+
+	timer := time.NewTimer(t.delay)
+	defer timer.Stop()
+
+	pending := 1
+	var firstErr error
+	for {
+		select {
+		case <-timer.C:
+			if hedge, ok := cloneHedgedRequest(req); ok {
+				launch(hedge)
+				pending++
+			}
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				return t.win(result, cancels, results, pending), nil
+			}
+
+			cancels[result.index]()
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			if pending == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
+
+// win cancels the attempts other than the winning one, discarding their
+// responses, and returns the winning response whose body cancels its attempt
+// when closed.
+func (t *hedgeTransport) win(
+	winner hedgeResult,
+	cancels []context.CancelFunc,
+	results <-chan hedgeResult,
+	pending int,
+) *http.Response {
+	for i, cancel := range cancels {
+		if i != winner.index {
+			cancel()
+		}
+	}
+
+	go func() {
+		for ; pending > 0; pending-- {
+			if loser := <-results; loser.err == nil {
+				_ = loser.resp.Body.Close()
+			}
+		}
+	}()
+
+	winner.resp.Body = &cancelOnCloseBody{
+		ReadCloser: winner.resp.Body,
+		cancel:     cancels[winner.index],
+	}
+
+	return winner.resp
+}
+
+// cloneHedgedRequest returns the copy of the given request to hedge it,
+// or false if its body cannot be replayed.
+func cloneHedgedRequest(req *http.Request) (*http.Request, bool) {
+	hedge := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return hedge, true
+	}
+
+	if req.GetBody == nil {
+		return nil, false
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	hedge.Body = body
+
+	return hedge, true
+}
+
+// cancelOnCloseBody cancels the context of the request when the response
+// body is closed.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
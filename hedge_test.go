@@ -0,0 +1,87 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithHedge(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	canceled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fast" {
+			requests.Add(1)
+			_, _ = io.WriteString(w, "fast")
+			return
+		}
+
+		if requests.Add(1) == 1 {
+			<-r.Context().Done()
+			close(canceled)
+			return
+		}
+		_, _ = io.WriteString(w, "hedged")
+	}))
+	defer server.Close()
+
+	var got strings.Builder
+	err := Get(server.URL+"/slow", WithHedge(20*time.Millisecond), WithOK().ToWriter(&got))
+	require.NoError(t, err)
+	assert.Equal(t, "hedged", got.String())
+
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the slow attempt is not canceled")
+	}
+
+	requests.Store(0)
+	got.Reset()
+	err = Get(server.URL+"/fast", WithHedge(time.Second), WithOK().ToWriter(&got))
+	require.NoError(t, err)
+	assert.Equal(t, "fast", got.String())
+	assert.Equal(t, int32(1), requests.Load())
+
+	err = Post(server.URL, WithHedge(time.Second))
+	require.ErrorIs(t, err, ErrHedgeUnsafeMethod)
+	assert.EqualError(t, err, `hedged requests require a safe HTTP method, got "POST"`)
+}
+
+func Test_hedgeTransport_bothFail(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	client := withHedging(&http.Client{Transport: roundTripperFunc(
+		func(req *http.Request) (*http.Response, error) {
+			if attempts.Add(1) == 1 {
+				<-req.Context().Done()
+				return nil, req.Context().Err()
+			}
+			return nil, io.ErrUnexpectedEOF
+		},
+	)}, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err)
+
+	_, err = client.Transport.RoundTrip(req)
+	require.Error(t, err)
+	assert.Equal(t, int32(2), attempts.Load())
+}
@@ -0,0 +1,119 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrJSONPathNotFound is returned when the JSON document has no value
+// at the given dotted path, see [OKStatuses.ToJSONField].
+var ErrJSONPathNotFound = errors.New("JSON path not found")
+
+// decodeJSONField reads the JSON document from the given reader and decodes
+// its sub-document at the given dotted path to the given value by the given
+// decoder. The top-level "meta" member, if any, is decoded to the given meta
+// value, if it is not nil.
+func decodeJSONField(from io.Reader, to any, path string, meta any, decode Decoder) error {
+	var doc json.RawMessage
+	if err := json.NewDecoder(from).Decode(&doc); err != nil {
+		return err
+	}
+
+	field, err := extractJSONPath(doc, path)
+	if err != nil {
+		return err
+	}
+
+	if err := decode(bytes.NewReader(field), to); err != nil {
+		return err
+	}
+
+	if meta == nil {
+		return nil
+	}
+
+	metaField, err := extractJSONPath(doc, "meta")
+	if errors.Is(err, ErrJSONPathNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return decode(bytes.NewReader(metaField), meta)
+}
+
+// extractJSONPath returns the sub-document of the given JSON document
+// at the given dotted path, e.g., "data.items.0.id", where numeric components
+// index arrays. The empty path returns the whole document.
+func extractJSONPath(doc json.RawMessage, path string) (json.RawMessage, error) {
+	if path == "" {
+		return doc, nil
+	}
+
+	components := strings.Split(path, ".")
+	for i, component := range components {
+		child, err := jsonChild(doc, component)
+		if err != nil {
+			parent := "the root"
+			if i > 0 {
+				parent = strconv.Quote(strings.Join(components[:i], "."))
+			}
+
+			return nil, fmt.Errorf("%w: %q: %w at %s", ErrJSONPathNotFound, path, err, parent)
+		}
+		doc = child
+	}
+
+	return doc, nil
+}
+
+// jsonChild returns the member of the given JSON object by the given key,
+// or the element of the given JSON array by the given index.
+func jsonChild(doc json.RawMessage, component string) (json.RawMessage, error) {
+	doc = bytes.TrimSpace(doc)
+	if len(doc) == 0 {
+		return nil, errors.New("empty document")
+	}
+
+	switch doc[0] {
+	case '{':
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(doc, &object); err != nil {
+			return nil, err
+		}
+
+		child, ok := object[component]
+		if !ok {
+			return nil, fmt.Errorf("no key %q", component)
+		}
+
+		return child, nil
+	case '[':
+		index, err := strconv.Atoi(component)
+		if err != nil {
+			return nil, fmt.Errorf("non-numeric index %q", component)
+		}
+
+		var array []json.RawMessage
+		if err := json.Unmarshal(doc, &array); err != nil {
+			return nil, err
+		}
+
+		if index < 0 || index >= len(array) {
+			return nil, fmt.Errorf("index %d out of range [0:%d]", index, len(array))
+		}
+
+		return array[index], nil
+	default:
+		return nil, errors.New("not an object or array")
+	}
+}
@@ -0,0 +1,79 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+// Package jsonschemarqx validates HTTP response bodies against JSON Schema.
+//
+// It is a separate module, so that the JSON Schema dependency is not required
+// by the rqx module unless needed:
+//
+//	go get github.com/tsayukov/rqx/jsonschemarqx
+package jsonschemarqx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/tsayukov/optparams"
+
+	"github.com/tsayukov/rqx"
+)
+
+const schemaURL = "rqx-response-schema.json"
+
+// WithJSONSchema validates the body of the successful HTTP response against
+// the given JSON Schema before decoding it. See [rqx.WithValidateResponseBody].
+//
+// The schema is compiled once. If it is invalid, the error is returned
+// when the option is applied, before sending the request.
+func WithJSONSchema(schema []byte) rqx.Option {
+	compiled, err := compile(schema)
+
+	validate := rqx.WithValidateResponseBody(func(body io.Reader) error {
+		instance, err := jsonschema.UnmarshalJSON(body)
+		if err != nil {
+			return fmt.Errorf("response body is not valid JSON: %w", err)
+		}
+
+		if err := compiled.Validate(instance); err != nil {
+			return fmt.Errorf("response body does not match JSON schema: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return failing(validate, err)
+	}
+
+	return validate
+}
+
+// failing returns the option of the same type as the given one that causes
+// the given error when applied. The type of [rqx.Option] is inferred,
+// since its parameters are unexported.
+func failing[T any](_ optparams.Func[T], err error) optparams.Func[T] {
+	return func(*T) error {
+		return err
+	}
+}
+
+func compile(schema []byte) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(schemaURL, doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	return compiled, nil
+}
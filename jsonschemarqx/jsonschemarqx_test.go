@@ -0,0 +1,100 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package jsonschemarqx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsayukov/rqx"
+)
+
+const schema = `{
+	"type": "object",
+	"properties": {"id": {"type": "integer"}},
+	"required": ["id"]
+}`
+
+func Test_WithJSONSchema(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		schema   string
+		body     string
+		want     int
+		hasError bool
+	}{
+		{
+			name:   "Valid body",
+			schema: schema,
+			body:   `{"id":42}`,
+			want:   42,
+		},
+		{
+			name:     "Mismatched body",
+			schema:   schema,
+			body:     `{"id":"42"}`,
+			hasError: true,
+		},
+		{
+			name:     "Invalid schema",
+			schema:   `{"type":`,
+			body:     `{"id":42}`,
+			hasError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					_, _ = io.WriteString(w, tt.body)
+				}),
+			)
+			defer server.Close()
+
+			var result struct {
+				ID int `json:"id"`
+			}
+
+			err := rqx.Get(server.URL,
+				WithJSONSchema([]byte(tt.schema)),
+				rqx.WithOK().ToJSON(&result),
+			)
+
+			if tt.hasError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, result.ID)
+			}
+		})
+	}
+}
+
+func Test_WithJSONSchema_invalidSchema(t *testing.T) {
+	t.Parallel()
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	err := rqx.Get(server.URL,
+		WithJSONSchema([]byte(`{"type":`)),
+		rqx.WithOK().ToDiscard(),
+	)
+	require.ErrorContains(t, err, "invalid JSON schema")
+	assert.Zero(t, requests)
+}
@@ -0,0 +1,79 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+var (
+	defaultMaxURLLength   atomic.Int64
+	defaultMaxHeaderBytes atomic.Int64
+)
+
+// SetDefaultMaxURLLength sets the maximum length of the URL in bytes for all
+// requests that do not set it by [WithMaxURLLength]. A non-positive value
+// means no limit, which is the default. It is safe for concurrent use.
+func SetDefaultMaxURLLength(n int) {
+	defaultMaxURLLength.Store(int64(n))
+}
+
+// SetDefaultMaxHeaderBytes sets the maximum size of the request header
+// in bytes for all requests that do not set it by [WithMaxHeaderBytes].
+// A non-positive value means no limit, which is the default. It is safe
+// for concurrent use.
+func SetDefaultMaxHeaderBytes(n int) {
+	defaultMaxHeaderBytes.Store(int64(n))
+}
+
+// applyDefaultLimits sets the package-level limits that are not set
+// by the options, see [SetDefaultMaxURLLength] and [SetDefaultMaxHeaderBytes].
+func (params *doParams) applyDefaultLimits() {
+	if !params.maxURLLength.isSet {
+		params.maxURLLength = some(int(defaultMaxURLLength.Load()))
+	}
+	params.urlValidator.maxLength = params.maxURLLength.value
+
+	if !params.maxHeaderBytes.isSet {
+		params.maxHeaderBytes = some(int(defaultMaxHeaderBytes.Load()))
+	}
+}
+
+// ErrHeaderTooLarge is returned when the serialized request header exceeds
+// the limit set by [WithMaxHeaderBytes] or [SetDefaultMaxHeaderBytes].
+var ErrHeaderTooLarge = errors.New("request header too large")
+
+// checkHeaderSize returns the [ErrHeaderTooLarge] error naming the largest
+// header if the given header serialized as "Key: value\r\n" lines exceeds
+// the limit.
+func (params *doParams) checkHeaderSize(header http.Header) error {
+	limit := params.maxHeaderBytes.value
+	if limit <= 0 {
+		return nil
+	}
+
+	var total, largest int
+	var largestKey string
+	for key, values := range header {
+		size := 0
+		for _, value := range values {
+			size += len(key) + len(": ") + len(value) + len("\r\n")
+		}
+
+		total += size
+		if size > largest || (size == largest && key < largestKey) {
+			largest, largestKey = size, key
+		}
+	}
+
+	if total <= limit {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %d bytes exceed the limit of %d bytes, the largest is %s (%d bytes)",
+		ErrHeaderTooLarge, total, limit, largestKey, largest)
+}
@@ -0,0 +1,80 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithMaxURLLength(t *testing.T) {
+	t.Parallel()
+
+	_, err := BuildURL("https://example.com", WithURLPaths("users"), WithMaxURLLength(25))
+	require.NoError(t, err)
+
+	long := strings.Repeat("a", 200)
+	err = Get("https://example.com", WithURLPaths(long), WithMaxURLLength(100))
+
+	var urlErr *InvalidURLError
+	require.ErrorAs(t, err, &urlErr)
+	assert.Equal(t, URLPartLength, urlErr.Part)
+	assert.Equal(t, "https://example.com/"+long, urlErr.URL)
+	assert.EqualError(t, err, `invalid URL "https://example.com/`+long[:108]+`"...: `+
+		`length: 220 bytes exceed the limit of 100 bytes`)
+}
+
+func Test_WithMaxHeaderBytes(t *testing.T) {
+	t.Parallel()
+
+	client := &http.Client{Transport: roundTripperFunc(
+		func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		},
+	)}
+
+	opts := []Option{
+		WithClient(client),
+		WithHeader("X-Small", "1"),
+		WithHandlerBeforeResponse(func(req *http.Request) error {
+			req.Header.Set("X-Large", strings.Repeat("a", 100))
+			return nil
+		}),
+		WithOK().ToDiscard(),
+	}
+
+	err := Get("https://example.com", append(opts, WithMaxHeaderBytes(200))...)
+	require.NoError(t, err)
+
+	err = Get("https://example.com", append(opts, WithMaxHeaderBytes(100))...)
+	require.ErrorIs(t, err, ErrHeaderTooLarge)
+	assert.EqualError(t, err, "request header too large: "+
+		"123 bytes exceed the limit of 100 bytes, the largest is X-Large (111 bytes)")
+}
+
+// Test_SetDefaultLimits is not parallel: it modifies the package-level defaults.
+func Test_SetDefaultLimits(t *testing.T) {
+	SetDefaultMaxURLLength(20)
+	SetDefaultMaxHeaderBytes(10)
+	defer SetDefaultMaxURLLength(0)
+	defer SetDefaultMaxHeaderBytes(0)
+
+	_, err := BuildURL("https://example.com/users")
+	require.Error(t, err)
+
+	_, err = BuildURL("https://example.com/users", WithMaxURLLength(0))
+	require.NoError(t, err)
+
+	params, err := newDoParams()
+	require.NoError(t, err)
+	require.ErrorIs(t, params.checkHeaderSize(http.Header{"X-Key": {"value"}}), ErrHeaderTooLarge)
+
+	params, err = newDoParams(WithMaxHeaderBytes(-1))
+	require.NoError(t, err)
+	assert.NoError(t, params.checkHeaderSize(http.Header{"X-Key": {"value"}}))
+}
@@ -0,0 +1,44 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// logRequest logs the attempt to send the request at the debug level,
+// if the logger is set. The response is nil if the request failed before
+// receiving it.
+func (params *doParams) logRequest(
+	req *http.Request,
+	resp *http.Response,
+	elapsed time.Duration,
+	err error,
+) {
+	if params.logger == nil {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 6)
+	attrs = append(attrs,
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.Redacted()),
+		slog.Duration("duration", elapsed),
+	)
+
+	if resp != nil {
+		attrs = append(attrs,
+			slog.Int("status", resp.StatusCode),
+			slog.Int64("body_size", resp.ContentLength),
+		)
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+
+	params.logger.LogAttrs(req.Context(), slog.LevelDebug, "rqx: request", attrs...)
+}
@@ -0,0 +1,74 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithLogger(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "hello")
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	url := strings.Replace(server.URL, "http://", "http://user:secret@", 1)
+	err := Get(url+"/items", WithLogger(logger), WithOK().ToDiscard())
+	require.NoError(t, err)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "DEBUG", record["level"])
+	assert.Equal(t, "rqx: request", record["msg"])
+	assert.Equal(t, "GET", record["method"])
+	assert.Equal(t, strings.Replace(url, "secret", "xxxxx", 1)+"/items", record["url"])
+	assert.Contains(t, record, "duration")
+	assert.InDelta(t, http.StatusOK, record["status"], 0)
+	assert.InDelta(t, 5, record["body_size"], 0)
+	assert.NotContains(t, record, "error")
+	assert.NotContains(t, buf.String(), "secret")
+	assert.NotContains(t, buf.String(), "hello")
+
+	// The failed attempt is logged without the response.
+	buf.Reset()
+	errTransport := errors.New("connection refused")
+	err = Get(server.URL,
+		WithClient(&http.Client{Transport: roundTripperFunc(
+			func(*http.Request) (*http.Response, error) { return nil, errTransport },
+		)}),
+		WithLogger(logger),
+	)
+	require.ErrorIs(t, err, errTransport)
+
+	record = nil
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.NotContains(t, record, "status")
+	assert.Contains(t, record["error"], "connection refused")
+
+	// Nothing is logged above the debug level or without a logger.
+	buf.Reset()
+	infoLogger := slog.New(slog.NewJSONHandler(&buf, nil))
+	err = Get(server.URL, WithLogger(infoLogger), WithOK().ToDiscard())
+	require.NoError(t, err)
+	assert.Empty(t, buf.String())
+
+	err = Get(server.URL, WithLogger(nil), WithOK().ToDiscard())
+	require.NoError(t, err)
+}
@@ -0,0 +1,70 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+	"net/http"
+)
+
+// metaContextKey is the context key of the request metadata.
+type metaContextKey struct{}
+
+// withMeta returns a copy of the given context carrying the given metadata.
+func withMeta(ctx context.Context, meta map[any]any) context.Context {
+	if len(meta) == 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, metaContextKey{}, meta)
+}
+
+// contextValue is the key-value pair added by [WithContextValue].
+type contextValue struct {
+	key, value any
+}
+
+// withContextValues returns a copy of the given context carrying the given
+// values layered in order, so the later value for the same key wins.
+func withContextValues(ctx context.Context, values []contextValue) context.Context {
+	for _, v := range values {
+		ctx = context.WithValue(ctx, v.key, v.value)
+	}
+
+	return ctx
+}
+
+// MetaFromContext returns the request metadata value set by [WithMeta]
+// for the given key, if any.
+func MetaFromContext(ctx context.Context, key any) (any, bool) {
+	meta, ok := ctx.Value(metaContextKey{}).(map[any]any)
+	if !ok {
+		return nil, false
+	}
+
+	value, ok := meta[key]
+
+	return value, ok
+}
+
+// MetaFromRequest returns the request metadata value set by [WithMeta]
+// for the given key, if any. Use [net/http.Response.Request] to get
+// the metadata in [AfterResponseHandler].
+func MetaFromRequest(req *http.Request, key any) (any, bool) {
+	return MetaFromContext(req.Context(), key)
+}
+
+// Meta returns the request metadata value of type T set by [WithMeta]
+// for the given key, if any and if it has type T.
+func Meta[T any](req *http.Request, key any) (T, bool) {
+	value, ok := MetaFromRequest(req, key)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+
+	typed, ok := value.(T)
+
+	return typed, ok
+}
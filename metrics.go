@@ -0,0 +1,30 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"net/http"
+	"time"
+)
+
+// Observer records the observed values, e.g., prometheus.Histogram or
+// prometheus.Summary, see [WithLatencyHistogram].
+type Observer interface {
+	Observe(value float64)
+}
+
+// reportRequest logs the attempt to send the request and records its duration,
+// see [WithLogger] and [WithLatencyHistogram].
+func (params *doParams) reportRequest(
+	req *http.Request,
+	resp *http.Response,
+	elapsed time.Duration,
+	err error,
+) {
+	params.logRequest(req, resp, elapsed, err)
+
+	if params.latency != nil {
+		params.latency.Observe(elapsed.Seconds())
+	}
+}
@@ -0,0 +1,51 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type histogram struct {
+	mu     sync.Mutex
+	values []float64
+}
+
+func (h *histogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.values = append(h.values, value)
+}
+
+func Test_WithLatencyHistogram(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+	}))
+	url := server.URL
+
+	var h histogram
+	err := Get(url, WithLatencyHistogram(&h), WithOK().ToDiscard())
+	require.NoError(t, err)
+	require.Len(t, h.values, 1)
+	assert.GreaterOrEqual(t, h.values[0], 0.01)
+
+	server.Close()
+
+	err = Get(url, WithLatencyHistogram(&h), WithOK().ToDiscard())
+	require.Error(t, err)
+	assert.Len(t, h.values, 2)
+
+	err = Get(url, WithLatencyHistogram(nil))
+	require.Error(t, err)
+}
@@ -0,0 +1,20 @@
+module github.com/tsayukov/rqx/msgpackrqx
+
+go 1.21
+
+require (
+	github.com/stretchr/testify v1.10.0
+	github.com/tsayukov/rqx v0.0.0-20261015053857-932497c284be
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/google/go-querystring v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/tsayukov/optparams v0.2.0 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	golang.org/x/net v0.35.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,45 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+// Package msgpackrqx encodes and decodes MessagePack HTTP bodies.
+//
+// It is a separate module, so that the msgpack dependency is not required
+// by the rqx module unless needed:
+//
+//	go get github.com/tsayukov/rqx/msgpackrqx
+package msgpackrqx
+
+import (
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/tsayukov/rqx"
+)
+
+// ContentMsgpack is the content type of MessagePack bodies.
+const ContentMsgpack rqx.ContentType = "application/msgpack"
+
+// WithMsgpack encodes the given data in MessagePack format as the body content
+// and sets the content type as "application/msgpack". If the body is already
+// set, it causes the [rqx.ErrBodyAlreadyExists] error.
+func WithMsgpack(data any) rqx.Option {
+	return rqx.WithEncodedNamed("msgpackrqx.WithMsgpack", data, Encoder, string(ContentMsgpack))
+}
+
+// ToMsgpack sets a handler for the given [rqx.OKStatuses]. The handler reads
+// and stores MessagePack-decoded [net/http.Response.Body] to the value
+// pointed to by the given result.
+func ToMsgpack(o rqx.OKStatuses, result any) rqx.Option {
+	return o.To(result, Decoder)
+}
+
+// Encoder is [rqx.Encoder] for MessagePack bodies.
+func Encoder(to io.Writer, from any) error {
+	return msgpack.NewEncoder(to).Encode(from)
+}
+
+// Decoder is [rqx.Decoder] for MessagePack bodies.
+func Decoder(from io.Reader, to any) error {
+	return msgpack.NewDecoder(from).Decode(to)
+}
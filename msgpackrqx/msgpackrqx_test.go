@@ -0,0 +1,53 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package msgpackrqx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsayukov/rqx"
+)
+
+type item struct {
+	ID   int      `msgpack:"id"`
+	Name string   `msgpack:"name"`
+	Tags []string `msgpack:"tags"`
+}
+
+func Test_WithMsgpack(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, string(ContentMsgpack), r.Header.Get(string(rqx.HeaderContentType)))
+
+		w.Header().Set(string(rqx.HeaderContentType), string(ContentMsgpack))
+		_, _ = io.Copy(w, r.Body)
+	}))
+	defer server.Close()
+
+	in := item{ID: 1, Name: "echo", Tags: []string{"a", "b"}}
+
+	var out item
+
+	err := rqx.Post(server.URL,
+		WithMsgpack(in),
+		ToMsgpack(rqx.WithOK(), &out),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, in, out)
+
+	err = rqx.Post(server.URL,
+		rqx.WithBytes([]byte("data")),
+		WithMsgpack(in),
+	)
+	require.ErrorIs(t, err, rqx.ErrBodyAlreadyExists)
+	assert.EqualError(t, err,
+		"body already exists: set by WithBytes, cannot apply msgpackrqx.WithMsgpack")
+}
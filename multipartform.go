@@ -11,9 +11,14 @@ import (
 	"mime/multipart"
 	"net/textproto"
 	"os"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 )
 
+const formTag = "form"
+
 // MultipartFormBuilder is a builder to constructs consecutive multipart
 // sections.
 type MultipartFormBuilder struct {
@@ -46,6 +51,106 @@ func (b *MultipartFormBuilder) AddString(fieldName, content string) *MultipartFo
 	return b.writePart(w, strings.NewReader(content))
 }
 
+// AddFields adds a new multipart section for each key-value pair of the given
+// map in the sorted order of the keys, see [MultipartFormBuilder.AddString].
+func (b *MultipartFormBuilder) AddFields(fields map[string]string) *MultipartFormBuilder {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		b.AddString(key, fields[key])
+	}
+
+	return b
+}
+
+// AddStruct adds a new multipart section for each exported field of the given
+// struct, or pointer to struct, with the `form:"name"` tag, see
+// [MultipartFormBuilder.AddString]. The "omitempty" option, e.g.,
+// `form:"name,omitempty"`, skips the field with the zero value, and the "-"
+// name skips the field at all.
+//
+// The field must be a string, bool, signed or unsigned integer, float,
+// or a slice or array of them. A slice or array adds a section for each
+// of its elements with the same field name.
+//
+// A nil pointer is a no-op.
+func (b *MultipartFormBuilder) AddStruct(v any) *MultipartFormBuilder {
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return b
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return b.joinErrors(fmt.Errorf("form must be a struct or pointer to struct, got %T", v))
+	}
+
+	structType := value.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag, ok := field.Tag.Lookup(formTag)
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" || (opts == "omitempty" && value.Field(i).IsZero()) {
+			continue
+		}
+
+		values, err := formatFormField(value.Field(i))
+		if err != nil {
+			b.joinErrors(fmt.Errorf("form field %s: %w", field.Name, err))
+			continue
+		}
+
+		for _, s := range values {
+			b.AddString(name, s)
+		}
+	}
+
+	return b
+}
+
+func formatFormField(v reflect.Value) ([]string, error) {
+	if kind := v.Kind(); kind == reflect.Slice || kind == reflect.Array {
+		values := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			s, err := formatFormValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, s)
+		}
+
+		return values, nil
+	}
+
+	s, err := formatFormValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{s}, nil
+}
+
+func formatFormValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	default:
+		return formatRequestField(v)
+	}
+}
+
 // AddFile adds a new multipart section with a header using the given field name
 // and writes the file content to the section's body.
 func (b *MultipartFormBuilder) AddFile(fieldName string, file *os.File) *MultipartFormBuilder {
@@ -104,6 +209,38 @@ func (b *MultipartFormBuilder) AddAsFileWithType(
 	return b.writePart(w, content)
 }
 
+// AddFieldWithHeader adds a new multipart section with the given header
+// and writes the content to the section's body. If the header does not have
+// the Content-Disposition key, it is set using the given field name.
+// The given header is not modified.
+func (b *MultipartFormBuilder) AddFieldWithHeader(
+	fieldName string,
+	content io.Reader,
+	header textproto.MIMEHeader,
+) *MultipartFormBuilder {
+	if closer, ok := content.(io.Closer); ok {
+		defer func() { _ = closer.Close() }()
+	}
+
+	h := make(textproto.MIMEHeader, len(header)+1)
+	for key, values := range header {
+		h[textproto.CanonicalMIMEHeaderKey(key)] = append([]string(nil), values...)
+	}
+
+	if h.Get(string(HeaderContentDisposition)) == "" {
+		h.Set(string(HeaderContentDisposition),
+			fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(fieldName)),
+		)
+	}
+
+	w, err := b.mw.CreatePart(h)
+	if err != nil {
+		return b.joinErrors(err)
+	}
+
+	return b.writePart(w, content)
+}
+
 // Body creates a body with the multipart sections and the proper content type.
 func (b *MultipartFormBuilder) Body() Option {
 	return func(params *doParams) error {
@@ -111,12 +248,17 @@ func (b *MultipartFormBuilder) Body() Option {
 			return errors.Join(b.errs...)
 		}
 
+		if err := params.checkNoBody("MultipartFormBuilder.Body"); err != nil {
+			return err
+		}
+
 		if err := b.mw.Close(); err != nil {
 			return err
 		}
 
 		params.body = bytes.NewReader(b.buf.Bytes())
-		params.headers[string(HeaderContentType)] = []string{b.mw.FormDataContentType()}
+		params.bodySource = "MultipartFormBuilder.Body"
+		params.bodyType = b.mw.FormDataContentType()
 
 		return nil
 	}
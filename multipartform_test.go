@@ -0,0 +1,180 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MultipartFormBuilder_AddFieldsAndStruct(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(url.Values(r.MultipartForm.Value).Encode()))
+	}))
+	defer server.Close()
+
+	type form struct {
+		Name     string   `form:"name"`
+		Age      uint8    `form:"age"`
+		Admin    bool     `form:"admin"`
+		Score    float64  `form:"score"`
+		Tags     []string `form:"tag"`
+		Ratios   [2]int   `form:"ratio"`
+		Nickname string   `form:"nickname,omitempty"`
+		Skipped  string   `form:"-"`
+		Untagged string
+		private  string `form:"private"` //nolint:unused // checks unexported fields
+	}
+
+	tests := []struct {
+		name    string
+		builder *MultipartFormBuilder
+		want    url.Values
+	}{
+		{
+			name: "fields",
+			builder: WithMultipartForm().AddFields(map[string]string{
+				"b": "2",
+				"a": "1",
+			}),
+			want: url.Values{"a": {"1"}, "b": {"2"}},
+		},
+		{
+			name: "struct",
+			builder: WithMultipartForm().AddStruct(&form{
+				Name:     "alice",
+				Age:      30,
+				Admin:    true,
+				Score:    9.5,
+				Tags:     []string{"x", "y"},
+				Ratios:   [2]int{1, -1},
+				Skipped:  "skipped",
+				Untagged: "untagged",
+			}),
+			want: url.Values{
+				"name":  {"alice"},
+				"age":   {"30"},
+				"admin": {"true"},
+				"score": {"9.5"},
+				"tag":   {"x", "y"},
+				"ratio": {"1", "-1"},
+			},
+		},
+		{
+			name: "no-op",
+			builder: WithMultipartForm().
+				AddFields(nil).
+				AddStruct(struct{}{}).
+				AddStruct((*form)(nil)).
+				AddString("only", "value"),
+			want: url.Values{"only": {"value"}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var got strings.Builder
+			err := Post(server.URL, tt.builder.Body(), WithOK().ToWriter(&got))
+			require.NoError(t, err)
+
+			values, err := url.ParseQuery(got.String())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, values)
+		})
+	}
+}
+
+func Test_MultipartFormBuilder_AddStructErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := newDoParams(WithMultipartForm().AddStruct(42).Body())
+	require.EqualError(t, err, "form must be a struct or pointer to struct, got int")
+
+	_, err = newDoParams(WithMultipartForm().AddStruct(struct {
+		Valid   string         `form:"valid"`
+		Invalid map[string]int `form:"invalid"`
+	}{}).Body())
+	require.EqualError(t, err, "form field Invalid: unsupported type map[string]int")
+}
+
+type closeTrackingReader struct {
+	*strings.Reader
+	closed bool
+}
+
+func (r *closeTrackingReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+func Test_MultipartFormBuilder_AddFieldWithHeader(t *testing.T) {
+	t.Parallel()
+
+	type part struct {
+		Header textproto.MIMEHeader
+		Body   string
+	}
+
+	var parts []part
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader, err := r.MultipartReader()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		for {
+			p, err := reader.NextPart()
+			if err != nil {
+				return
+			}
+
+			body, _ := io.ReadAll(p)
+			parts = append(parts, part{Header: p.Header, Body: string(body)})
+		}
+	}))
+	defer server.Close()
+
+	jsonHeader := textproto.MIMEHeader{"content-type": {"application/json"}}
+	fileHeader := textproto.MIMEHeader{
+		"Content-Disposition": {`form-data; name="upload"; filename="a.txt"`},
+	}
+	content := &closeTrackingReader{Reader: strings.NewReader("file content")}
+
+	form := WithMultipartForm().
+		AddFieldWithHeader(`meta"data`, strings.NewReader(`{"a":1}`), jsonHeader).
+		AddFieldWithHeader("ignored", content, fileHeader)
+
+	err := Post(server.URL, form.Body(), WithExpectStatus(http.StatusOK))
+	require.NoError(t, err)
+
+	require.Len(t, parts, 2)
+	assert.Equal(t, `form-data; name="meta\"data"`, parts[0].Header.Get("Content-Disposition"))
+	assert.Equal(t, "application/json", parts[0].Header.Get("Content-Type"))
+	assert.Equal(t, `{"a":1}`, parts[0].Body)
+
+	assert.Equal(t, `form-data; name="upload"; filename="a.txt"`,
+		parts[1].Header.Get("Content-Disposition"))
+	assert.Equal(t, "file content", parts[1].Body)
+	assert.True(t, content.closed)
+
+	// The given headers are not modified.
+	assert.Equal(t, textproto.MIMEHeader{"content-type": {"application/json"}}, jsonHeader)
+	assert.Len(t, fileHeader, 1)
+}
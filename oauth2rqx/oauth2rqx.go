@@ -0,0 +1,185 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+// Package oauth2rqx authorizes HTTP requests by OAuth 2.0 access tokens
+// obtained using the client credentials grant.
+package oauth2rqx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tsayukov/optparams"
+
+	"github.com/tsayukov/rqx"
+)
+
+// expirySkew is subtracted from the token lifetime, so the token is refreshed
+// before it expires on the server side.
+const expirySkew = 10 * time.Second
+
+const bearerPrefix = "Bearer "
+
+// TokenError is an error response of the token endpoint, see RFC 6749,
+// section 5.2.
+type TokenError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+	URI         string `json:"error_uri"`
+}
+
+func (e *TokenError) Error() string {
+	if e.Description == "" {
+		return "oauth2: " + e.Code
+	}
+
+	return fmt.Sprintf("oauth2: %s: %s", e.Code, e.Description)
+}
+
+var _ error = (*TokenError)(nil)
+
+// WithClientCredentials authorizes the request by the access token obtained
+// from the given token endpoint using the client credentials grant,
+// see RFC 6749, section 4.4. The token is sent as a Bearer token in
+// the Authorization header. The given options are applied to the token
+// request, e.g., [rqx.WithClient] to use the same client as the authorized
+// requests.
+//
+// The token is cached with its expiry, minus a small skew, by the returned
+// option, so create the option once and share it by all requests with
+// the same credentials: parallel requests then fetch the token only once.
+// The cache, along with the credentials, is dropped with the option.
+// On the first 401 response, the cached token is invalidated and the request
+// is retried once with a new token, see [rqx.WithRetryOnUnauthorized].
+func WithClientCredentials(
+	tokenURL, clientID, clientSecret string,
+	scopes []string,
+	tokenOpts ...rqx.Option,
+) rqx.Option {
+	source := &tokenSource{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       strings.Join(scopes, " "),
+		opts:         slices.Clone(tokenOpts),
+		lock:         make(chan struct{}, 1),
+	}
+
+	return optparams.Join(
+		rqx.WithHandlerBeforeResponse(func(req *http.Request) error {
+			token, err := source.token(req.Context())
+			if err != nil {
+				return err
+			}
+
+			req.Header.Set(string(rqx.HeaderAuthorization), bearerPrefix+token)
+
+			return nil
+		}),
+		rqx.WithRetryOnUnauthorized(func(_ context.Context, resp *http.Response) error {
+			auth := resp.Request.Header.Get(string(rqx.HeaderAuthorization))
+			source.invalidate(strings.TrimPrefix(auth, bearerPrefix))
+			return nil
+		}),
+	)
+}
+
+// tokenSource caches the access token obtained by the given credentials.
+type tokenSource struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       string
+	opts         []rqx.Option
+
+	// lock guards the fields below and serializes the token requests,
+	// respecting the context cancellation of the waiting callers.
+	lock   chan struct{}
+	value  string
+	expiry time.Time
+}
+
+func (s *tokenSource) token(ctx context.Context) (string, error) {
+	select {
+	case s.lock <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	defer func() { <-s.lock }()
+
+	if s.value != "" && (s.expiry.IsZero() || time.Now().Before(s.expiry)) {
+		return s.value, nil
+	}
+
+	value, expiry, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	s.value, s.expiry = value, expiry
+
+	return value, nil
+}
+
+// invalidate drops the cached token if it is the given one, so the token
+// refreshed by a concurrent request is kept.
+func (s *tokenSource) invalidate(value string) {
+	s.lock <- struct{}{}
+	defer func() { <-s.lock }()
+
+	if s.value == value {
+		s.value, s.expiry = "", time.Time{}
+	}
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+var errNoAccessToken = errors.New("oauth2: no access token in the response")
+
+func (s *tokenSource) fetch(ctx context.Context) (string, time.Time, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if s.scopes != "" {
+		form.Set("scope", s.scopes)
+	}
+
+	var resp tokenResponse
+
+	opts := make([]rqx.Option, 0, len(s.opts)+7)
+	opts = append(opts, s.opts...)
+	opts = append(opts,
+		rqx.WithContext(ctx),
+		rqx.WithBasicAuth(url.QueryEscape(s.clientID), url.QueryEscape(s.clientSecret)),
+		rqx.WithBytes([]byte(form.Encode())),
+		rqx.WithContentType(string(rqx.ContentFormURLEncoded)),
+		rqx.WithAccept(string(rqx.ContentJSON)),
+		rqx.WithOK().ToJSON(&resp),
+		rqx.WithError4xx[*TokenError]().ToJSON(),
+	)
+
+	err := rqx.Post(s.tokenURL, opts...)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if resp.AccessToken == "" {
+		return "", time.Time{}, errNoAccessToken
+	}
+
+	var expiry time.Time
+	if resp.ExpiresIn > 0 {
+		lifetime := time.Duration(resp.ExpiresIn) * time.Second
+		expiry = time.Now().Add(lifetime - min(expirySkew, lifetime/2))
+	}
+
+	return resp.AccessToken, expiry, nil
+}
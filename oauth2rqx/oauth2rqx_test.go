@@ -0,0 +1,151 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package oauth2rqx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsayukov/rqx"
+)
+
+func newTokenServer(t *testing.T, issued *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+
+		assert.Equal(t, "client_credentials", r.PostFormValue("grant_type"))
+		assert.Equal(t, "read write", r.PostFormValue("scope"))
+
+		time.Sleep(10 * time.Millisecond) // let the parallel requests pile up
+
+		n := issued.Add(1)
+		_ = json.NewEncoder(w).Encode(tokenResponse{
+			AccessToken: fmt.Sprintf("token-%d", n),
+			TokenType:   "Bearer",
+			ExpiresIn:   3600,
+		})
+	}))
+}
+
+func Test_WithClientCredentials(t *testing.T) {
+	t.Parallel()
+
+	var issued atomic.Int32
+	tokenServer := newTokenServer(t, &issued)
+	defer tokenServer.Close()
+
+	var revoked atomic.Bool
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get(string(rqx.HeaderAuthorization))
+		if auth == "Bearer token-1" && revoked.Load() {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(auth))
+	}))
+	defer api.Close()
+
+	scopes := []string{"read", "write"}
+	credentials := WithClientCredentials(tokenServer.URL, "client", "secret", scopes)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			assert.NoError(t, rqx.Get(api.URL, credentials, rqx.WithOK().ToDiscard()))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), issued.Load())
+
+	revoked.Store(true)
+
+	var got strings.Builder
+	err := rqx.Get(api.URL, credentials, rqx.WithOK().ToWriter(&got))
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token-2", got.String())
+	assert.Equal(t, int32(2), issued.Load())
+}
+
+func Test_WithClientCredentials_errors(t *testing.T) {
+	t.Parallel()
+
+	var issued atomic.Int32
+	tokenServer := newTokenServer(t, &issued)
+	defer tokenServer.Close()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer api.Close()
+
+	err := rqx.Get(api.URL,
+		WithClientCredentials(tokenServer.URL, "client", "wrong", nil),
+		rqx.WithExpectStatus(http.StatusOK),
+	)
+	var tokenErr *TokenError
+	require.ErrorAs(t, err, &tokenErr)
+	assert.Equal(t, "invalid_client", tokenErr.Code)
+
+	// The request is retried only once on 401.
+	err = rqx.Get(api.URL,
+		WithClientCredentials(tokenServer.URL, "client", "secret", []string{"read", "write"}),
+		rqx.WithExpectStatus(http.StatusOK),
+	)
+	require.Error(t, err)
+	assert.Equal(t, int32(2), issued.Load())
+}
+
+func Test_WithClientCredentials_tokenOptions(t *testing.T) {
+	t.Parallel()
+
+	tokenServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+		}),
+	)
+	defer tokenServer.Close()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get(string(rqx.HeaderAuthorization))))
+	}))
+	defer api.Close()
+
+	// The token endpoint is not trusted by the default client.
+	err := rqx.Get(api.URL,
+		WithClientCredentials(tokenServer.URL, "client", "secret", nil),
+		rqx.WithOK().ToDiscard(),
+	)
+	require.Error(t, err)
+
+	var got strings.Builder
+	err = rqx.Get(api.URL,
+		WithClientCredentials(tokenServer.URL, "client", "secret", nil,
+			rqx.WithClient(tokenServer.Client()),
+		),
+		rqx.WithOK().ToWriter(&got),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token", got.String())
+}
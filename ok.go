@@ -4,27 +4,76 @@
 package rqx
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
-	"slices"
+	"reflect"
 )
 
 // OKStatuses are HTTP response status codes that are successful.
 type OKStatuses responseStatuses
 
+// ErrInvalidResult is returned by [OKStatuses.To] and alike when the given
+// result is not a non-nil pointer, or not of the type required, e.g.,
+// by [OKStatuses.ToMap], so the response body cannot be decoded to it.
+// It is returned before sending the request.
+var ErrInvalidResult = errors.New("rqx: result must be a non-nil pointer")
+
+// checkResult returns the [ErrInvalidResult] error naming the type
+// of the given result if it is not a non-nil pointer.
+func checkResult(result any) error {
+	value := reflect.ValueOf(result)
+	switch {
+	case !value.IsValid():
+		return fmt.Errorf("%w, got nil", ErrInvalidResult)
+	case value.Kind() != reflect.Pointer:
+		return fmt.Errorf("%w, got %T", ErrInvalidResult, result)
+	case value.IsNil():
+		return fmt.Errorf("%w, got nil %T", ErrInvalidResult, result)
+	}
+
+	return nil
+}
+
 // To sets a handler for [OKStatuses]. The handler uses [Decoder] to read
 // and store decoded [net/http.Response.Body] to the value
-// pointed to by the given result.
+// pointed to by the given result. Before decoding, the body is checked
+// by the validators added by [WithValidateResponseBody]. If the result is not
+// a non-nil pointer, it causes the [ErrInvalidResult] error.
 func (o OKStatuses) To(result any, decoder Decoder) Option {
+	return o.ToThen(result, decoder, nil)
+}
+
+// ToThen works like [OKStatuses.To], but also calls the given function
+// only when the status code matches and decoding succeeds, e.g., to validate
+// the decoded result. The error returned by the function is returned by [Do].
+func (o OKStatuses) ToThen(result any, decoder Decoder, then func() error) Option {
 	return func(params *doParams) error {
+		if err := checkResult(result); err != nil {
+			return err
+		}
+
+		params.handler.okMediaType = ""
 		params.handler.okResponse = func(resp *http.Response) (any, error) {
-			if !slices.Contains(o, resp.StatusCode) {
+			if !responseStatuses(o).contains(resp.StatusCode) {
 				return nil, nil
 			}
 
-			if err := decoder(resp.Body, result); err != nil {
+			if err := params.handler.validateBody(resp); err != nil {
 				return nil, err
 			}
 
+			if err := params.handler.decode(decoder, resp.Body, result); err != nil {
+				return nil, err
+			}
+
+			if then != nil {
+				if err := then(); err != nil {
+					return nil, err
+				}
+			}
+
 			return result, nil
 		}
 
@@ -36,12 +85,186 @@ func (o OKStatuses) To(result any, decoder Decoder) Option {
 // JSON-decoded [net/http.Response.Body] to the value pointed to by the given
 // result.
 func (o OKStatuses) ToJSON(result any) Option {
-	return o.To(result, jsonDecoder)
+	return withOKMediaType(ContentJSON, func(params *doParams) error {
+		return o.To(result, params.handler.decodeJSON)(params)
+	})
 }
 
 // ToXML sets a handler for [OKStatuses]. The handler reads and stores
 // XML-decoded [net/http.Response.Body] to the value pointed to by the given
 // result.
 func (o OKStatuses) ToXML(result any) Option {
-	return o.To(result, xmlDecoder)
+	return withOKMediaType(ContentXML, o.To(result, xmlDecoder))
+}
+
+// ToCSV sets a handler for [OKStatuses]. The handler reads CSV-encoded
+// [net/http.Response.Body] with a header row and stores the records
+// to the slice of structs, or pointers to structs, pointed to by the given
+// result. The columns are matched to the fields by the "csv" tag, e.g.,
+// `csv:"id"`; the unmatched columns are skipped.
+//
+// The supported field types are string, bool, signed and unsigned integers,
+// floats, and types implementing [encoding.TextUnmarshaler]. Empty values
+// leave the zero value. An unparsable value causes the [CSVFieldError] error.
+// See [WithCSVDelimiter] to use another delimiter.
+func (o OKStatuses) ToCSV(result any) Option {
+	return withOKMediaType(ContentCSV, func(params *doParams) error {
+		return o.To(result, func(from io.Reader, to any) error {
+			return csvDecoder(from, to, params.handler.csvDelimiter)
+		})(params)
+	})
+}
+
+// ToMap sets a handler for [OKStatuses]. The handler reads JSON-encoded
+// [net/http.Response.Body] and stores it to the value pointed to by the given
+// result, which must be *map[string]any, *[]any for the top-level array,
+// or *any, e.g., for exploratory calls without declaring the structs.
+// The numbers are stored as [encoding/json.Number], so the big IDs are not
+// corrupted by float64; see [WithJSONInt64] to get int64 where they fit.
+func (o OKStatuses) ToMap(result any) Option {
+	return withOKMediaType(ContentJSON, func(params *doParams) error {
+		switch result.(type) {
+		case *map[string]any, *[]any, *any:
+		default:
+			return fmt.Errorf("%w to map[string]any, []any, or any, got %T",
+				ErrInvalidResult, result)
+		}
+
+		return o.To(result, func(from io.Reader, to any) error {
+			return jsonMapDecoder(from, to, params.handler.jsonInt64)
+		})(params)
+	})
+}
+
+// ToJSONThen works like [OKStatuses.ToJSON], but also calls the given function
+// only when the status code matches and decoding succeeds. See
+// [OKStatuses.ToThen].
+func (o OKStatuses) ToJSONThen(result any, then func() error) Option {
+	return withOKMediaType(ContentJSON, func(params *doParams) error {
+		return o.ToThen(result, params.handler.decodeJSON, then)(params)
+	})
+}
+
+// ToJSONField sets a handler for [OKStatuses]. The handler reads JSON-encoded
+// [net/http.Response.Body] and stores the sub-document at the given dotted
+// path, e.g., "data" or "data.items.0", to the value pointed to by the given
+// result, so the response envelope need not be declared. Numeric components
+// of the path index arrays. If there is no value at the path, it causes
+// the [ErrJSONPathNotFound] error.
+//
+// If the meta target is given, the top-level "meta" member, if any, is stored
+// to the value pointed to by it as well. At most one meta target is allowed.
+func (o OKStatuses) ToJSONField(result any, path string, metaTarget ...any) Option {
+	return withOKMediaType(ContentJSON, func(params *doParams) error {
+		var meta any
+		switch len(metaTarget) {
+		case 0:
+		case 1:
+			if err := checkResult(metaTarget[0]); err != nil {
+				return fmt.Errorf("meta target: %w", err)
+			}
+			meta = metaTarget[0]
+		default:
+			return errors.New("at most one meta target is allowed")
+		}
+
+		return o.To(result, func(from io.Reader, to any) error {
+			return decodeJSONField(from, to, path, meta, params.handler.decodeJSON)
+		})(params)
+	})
+}
+
+// ToXMLThen works like [OKStatuses.ToXML], but also calls the given function
+// only when the status code matches and decoding succeeds. See
+// [OKStatuses.ToThen].
+func (o OKStatuses) ToXMLThen(result any, then func() error) Option {
+	return withOKMediaType(ContentXML, o.ToThen(result, xmlDecoder, then))
+}
+
+// withOKMediaType works like the given option setting the handler
+// for [OKStatuses], but also records the media type the handler decodes,
+// see [WithAutoAccept].
+func withOKMediaType(mediaType ContentType, opt Option) Option {
+	return func(params *doParams) error {
+		if err := opt(params); err != nil {
+			return err
+		}
+
+		params.handler.okMediaType = mediaType
+
+		return nil
+	}
+}
+
+// discarded is the result of the handler set by [OKStatuses.ToDiscard].
+type discarded struct{}
+
+// ToDiscard sets a handler for [OKStatuses]. The handler discards
+// [net/http.Response.Body] without decoding it, so [Do] reports success
+// when the status code matches.
+func (o OKStatuses) ToDiscard() Option {
+	return func(params *doParams) error {
+		params.handler.okMediaType = ""
+		params.handler.okResponse = func(resp *http.Response) (any, error) {
+			if !responseStatuses(o).contains(resp.StatusCode) {
+				return nil, nil
+			}
+
+			if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+				return nil, err
+			}
+
+			return discarded{}, nil
+		}
+
+		return nil
+	}
+}
+
+// ToWriter sets a handler for [OKStatuses]. The handler copies
+// [net/http.Response.Body] to the given writer as is, e.g., to download
+// the content to a file.
+func (o OKStatuses) ToWriter(w io.Writer) Option {
+	return func(params *doParams) error {
+		params.handler.okMediaType = ""
+		params.handler.okResponse = func(resp *http.Response) (any, error) {
+			if !responseStatuses(o).contains(resp.StatusCode) {
+				return nil, nil
+			}
+
+			if _, err := io.Copy(w, resp.Body); err != nil {
+				return nil, err
+			}
+
+			return w, nil
+		}
+
+		return nil
+	}
+}
+
+// ToFunc sets a handler for [OKStatuses]. The handler calls the given one
+// with the response, so it may read [net/http.Response.Body] as it sees fit,
+// e.g., depending on the status code.
+func (o OKStatuses) ToFunc(handler AfterResponseHandler) Option {
+	return func(params *doParams) error {
+		if handler == nil {
+			return errors.New("OK handler is nil")
+		}
+
+		params.handler.okMediaType = ""
+		params.handler.okResponse = func(resp *http.Response) (any, error) {
+			if !responseStatuses(o).contains(resp.StatusCode) {
+				return nil, nil
+			}
+
+			if err := handler(resp); err != nil {
+				return nil, err
+			}
+
+			return discarded{}, nil
+		}
+
+		return nil
+	}
 }
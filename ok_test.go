@@ -0,0 +1,333 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_OKStatuses_invalidResult(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		Name string
+	}
+
+	tests := []struct {
+		name    string
+		result  any
+		wantErr string
+	}{
+		{name: "nil", result: nil, wantErr: "rqx: result must be a non-nil pointer, got nil"},
+		{
+			name:    "value",
+			result:  user{},
+			wantErr: "rqx: result must be a non-nil pointer, got rqx.user",
+		},
+		{
+			name:    "nil pointer",
+			result:  (*user)(nil),
+			wantErr: "rqx: result must be a non-nil pointer, got nil *rqx.user",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The server is unreachable: the result is checked before sending.
+			err := Get("http://127.0.0.1:0", WithOK().ToJSON(tt.result))
+			require.ErrorIs(t, err, ErrInvalidResult)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+
+	var got user
+	err := Get("http://127.0.0.1:0", WithOK().ToXML(&got))
+	assert.NotErrorIs(t, err, ErrInvalidResult)
+}
+
+func Test_OKStatuses_ToJSONField(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{
+			"data": {"items": [{"id": 1}, {"id": 2}], "name": "list"},
+			"meta": {"page": 3}
+		}`)
+	}))
+	defer server.Close()
+
+	type item struct {
+		ID int `json:"id"`
+	}
+
+	type meta struct {
+		Page int `json:"page"`
+	}
+
+	var items []item
+	var m meta
+	err := Get(server.URL, WithOK().ToJSONField(&items, "data.items", &m))
+	require.NoError(t, err)
+	assert.Equal(t, []item{{ID: 1}, {ID: 2}}, items)
+	assert.Equal(t, meta{Page: 3}, m)
+
+	var second item
+	err = Get(server.URL, WithOK().ToJSONField(&second, "data.items.1"))
+	require.NoError(t, err)
+	assert.Equal(t, item{ID: 2}, second)
+
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{
+			path:    "payload",
+			wantErr: `JSON path not found: "payload": no key "payload" at the root`,
+		},
+		{
+			path: "data.items.2",
+			wantErr: `JSON path not found: "data.items.2": ` +
+				`index 2 out of range [0:2] at "data.items"`,
+		},
+		{
+			path: "data.items.first",
+			wantErr: `JSON path not found: "data.items.first": ` +
+				`non-numeric index "first" at "data.items"`,
+		},
+		{
+			path: "data.name.first",
+			wantErr: `JSON path not found: "data.name.first": ` +
+				`not an object or array at "data.name"`,
+		},
+	}
+	for _, tt := range tests {
+		var got any
+		err := Get(server.URL, WithOK().ToJSONField(&got, tt.path))
+		require.ErrorIs(t, err, ErrJSONPathNotFound)
+		assert.EqualError(t, err, tt.wantErr)
+	}
+
+	err = Get(server.URL, WithOK().ToJSONField(&items, "data.items", m))
+	require.ErrorIs(t, err, ErrInvalidResult)
+}
+
+func Test_OKStatuses_ToMap(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/list" {
+			_, _ = io.WriteString(w, `[{"id": 9007199254740993}, 1.5]`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"id": 9007199254740993, "big": 1e30, "tags": [1, "a"]}`)
+	}))
+	defer server.Close()
+
+	var m map[string]any
+	err := Get(server.URL, WithOK().ToMap(&m))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"id":   json.Number("9007199254740993"),
+		"big":  json.Number("1e30"),
+		"tags": []any{json.Number("1"), "a"},
+	}, m)
+
+	err = Get(server.URL, WithOK().ToMap(&m), WithJSONInt64())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"id":   int64(9007199254740993),
+		"big":  json.Number("1e30"),
+		"tags": []any{int64(1), "a"},
+	}, m)
+
+	var list []any
+	err = Get(server.URL+"/list", WithJSONInt64(), WithOK().ToMap(&list))
+	require.NoError(t, err)
+	assert.Equal(t, []any{map[string]any{"id": int64(9007199254740993)}, json.Number("1.5")}, list)
+
+	var anything any
+	err = Get(server.URL+"/list", WithJSONInt64(), WithOK().ToMap(&anything))
+	require.NoError(t, err)
+	assert.Equal(t, []any{map[string]any{"id": int64(9007199254740993)}, json.Number("1.5")},
+		anything)
+
+	var wrong map[string]int
+	err = Get(server.URL, WithOK().ToMap(&wrong))
+	require.ErrorIs(t, err, ErrInvalidResult)
+	require.EqualError(t, err, "rqx: result must be a non-nil pointer"+
+		" to map[string]any, []any, or any, got *map[string]int")
+
+	err = Get(server.URL, WithOK().ToMap((*map[string]any)(nil)))
+	require.ErrorIs(t, err, ErrInvalidResult)
+}
+
+func Test_WithExpectStatus(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"message": "not found"}`)
+		case "/limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	err := Get(server.URL, WithExpectStatus(http.StatusOK))
+	require.NoError(t, err)
+
+	err = Get(server.URL+"/created", WithExpectStatus(http.StatusOK))
+	var statusErr *UnexpectedStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusOK, statusErr.Expected)
+	assert.Equal(t, http.StatusCreated, statusErr.Actual)
+	require.EqualError(t, err, "unexpected response status 201 Created, expected 200 OK")
+
+	// The specific handlers take precedence regardless of the option order.
+	err = Get(server.URL+"/missing",
+		WithExpectStatus(http.StatusOK),
+		WithError[*apiError](http.StatusNotFound).ToJSON(),
+	)
+	var apiErr *apiError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "not found", apiErr.Message)
+
+	err = Get(server.URL+"/missing",
+		WithExpectStatus(http.StatusOK),
+		WithFailOnErrorStatus(),
+	)
+	var httpErr *HTTPStatusError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusNotFound, httpErr.Status)
+
+	errCooldown := errors.New("cooldown")
+	err = Get(server.URL+"/limited",
+		WithExpectStatus(http.StatusOK),
+		WithRateLimit(http.StatusTooManyRequests).Cooldown(
+			func(context.Context, *http.Response) error { return errCooldown },
+		),
+	)
+	require.ErrorIs(t, err, errCooldown)
+}
+
+func Test_OKStatuses_ToThen(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/xml":
+			_, _ = io.WriteString(w, `<user><id>7</id></user>`)
+		case "/malformed":
+			_, _ = io.WriteString(w, `{"id":`)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			_, _ = io.WriteString(w, `{"id":42}`)
+		}
+	}))
+	defer server.Close()
+
+	type user struct {
+		ID int `json:"id" xml:"id"`
+	}
+
+	var (
+		got   user
+		calls int
+		seen  int
+	)
+	then := func() error {
+		calls++
+		seen = got.ID
+		return nil
+	}
+
+	err := Get(server.URL, WithOK().ToJSONThen(&got, then))
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 42, seen)
+
+	err = Get(server.URL+"/xml", WithOK().ToXMLThen(&got, then))
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 7, seen)
+
+	err = Get(server.URL, WithOK().ToThen(&got, func(from io.Reader, to any) error {
+		return json.NewDecoder(from).Decode(to)
+	}, then))
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	// The function is not called if the status does not match
+	// or decoding fails.
+	err = Get(server.URL+"/missing", WithOK().ToJSONThen(&got, then))
+	require.ErrorIs(t, err, ErrUnhandledStatus(http.StatusNotFound))
+
+	err = Get(server.URL+"/malformed", WithOK().ToJSONThen(&got, then))
+	require.Error(t, err)
+	assert.Equal(t, 3, calls)
+
+	errInvalid := errors.New("invalid user")
+	err = Get(server.URL, WithOK().ToJSONThen(&got, func() error { return errInvalid }))
+	require.ErrorIs(t, err, errInvalid)
+
+	err = Get(server.URL, WithOK().ToJSONThen(got, then))
+	require.ErrorIs(t, err, ErrInvalidResult)
+}
+
+func Test_OKStatuses_ToFunc(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = io.WriteString(w, "content")
+	}))
+	defer server.Close()
+
+	var (
+		status int
+		body   []byte
+	)
+	handler := func(resp *http.Response) error {
+		var err error
+		status = resp.StatusCode
+		body, err = io.ReadAll(resp.Body)
+		return err
+	}
+
+	err := Get(server.URL, WithOK().ToFunc(handler))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "content", string(body))
+
+	status = 0
+	err = Get(server.URL+"/missing", WithOK().ToFunc(handler))
+	require.ErrorIs(t, err, ErrUnhandledStatus(http.StatusNotFound))
+	assert.Zero(t, status)
+
+	errFailed := errors.New("failed")
+	err = Get(server.URL, WithOK().ToFunc(func(*http.Response) error { return errFailed }))
+	require.ErrorIs(t, err, errFailed)
+
+	err = Get(server.URL, WithOK().ToFunc(nil))
+	require.Error(t, err)
+}
@@ -6,15 +6,28 @@ package rqx
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
-	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/netip"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/tsayukov/optparams"
 )
@@ -26,221 +39,1659 @@ func optionalBool[B ~bool](value ...B) bool {
 }
 
 // WithContext sets the given [context.Context] for the current request.
+// The context must not be nil.
 func WithContext(ctx context.Context) Option {
 	return func(params *doParams) error {
+		if ctx == nil {
+			return errors.New("WithContext: context is nil")
+		}
+
 		params.ctx = ctx
 		return nil
 	}
 }
 
-// WithClient sets the given [net/http.Client] for the current request.
-func WithClient(c *http.Client) Option {
+// WithCancelCause calls the given function with the error of sending
+// the request or handling the response as the cause, e.g., to cancel
+// the context shared by a batch of requests on the first failure, so
+// [context.Cause] reports it. The errors of the options and of building
+// the URL do not call the function.
+func WithCancelCause(cancel context.CancelCauseFunc) Option {
+	return func(params *doParams) error {
+		if cancel == nil {
+			return errors.New("cancel func is nil")
+		}
+
+		params.cancelCause = cancel
+		return nil
+	}
+}
+
+// WithMeta stores the given metadata value for the given key, so
+// the handlers can get it from the request context using [Meta],
+// [MetaFromRequest], or [MetaFromContext]. Like context keys, the key
+// should be of an unexported type to avoid collisions.
+func WithMeta(key, value any) Option {
+	return func(params *doParams) error {
+		if params.meta == nil {
+			params.meta = make(map[any]any)
+		}
+
+		params.meta[key] = value
+
+		return nil
+	}
+}
+
+// WithContextValue adds the given key-value pair to the request context
+// using [context.WithValue], so the handlers can get the value from
+// [net/http.Request.Context], or [net/http.Response.Request] in
+// [AfterResponseHandler]. Unlike [WithContext], it does not replace
+// the context, so multiple calls layer the values regardless of the order
+// of the options. Like context keys, the key should be of an unexported type
+// to avoid collisions.
+func WithContextValue(key, value any) Option {
+	return func(params *doParams) error {
+		params.values = append(params.values, contextValue{key: key, value: value})
+		return nil
+	}
+}
+
+// WithClient sets the given [net/http.Client] for the current request.
+//
+// The transport options, e.g., [WithMaxIdleConnsPerHost], modify a clone
+// of the client transport, or [net/http.DefaultTransport] if it is nil,
+// preserving its other settings, e.g., TLS and proxy ones. In that case,
+// the client transport must be [net/http.Transport]. The clones are cached
+// by the original transport and the settings, so the connections are reused
+// across requests with the same settings. Only the recently used 64 clones
+// are kept; the idle connections of the evicted ones are closed.
+//
+// The client must not be nil.
+func WithClient(c *http.Client) Option {
+	return func(params *doParams) error {
+		if c == nil {
+			return errors.New("WithClient: client is nil")
+		}
+
+		params.client = c
+		return nil
+	}
+}
+
+// WithMaxIdleConnsPerHost sets [net/http.Transport.MaxIdleConnsPerHost]
+// of the client transport for the current request. See [WithClient]
+// for the transport options.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(params *doParams) error {
+		params.transport.maxIdleConnsPerHost = some(n)
+		return nil
+	}
+}
+
+// WithMaxConnsPerHost sets [net/http.Transport.MaxConnsPerHost] of the client
+// transport for the current request. See [WithClient] for the transport
+// options.
+func WithMaxConnsPerHost(n int) Option {
+	return func(params *doParams) error {
+		params.transport.maxConnsPerHost = some(n)
+		return nil
+	}
+}
+
+// WithIdleConnTimeout sets [net/http.Transport.IdleConnTimeout] of the client
+// transport for the current request. See [WithClient] for the transport
+// options.
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(params *doParams) error {
+		params.transport.idleConnTimeout = some(d)
+		return nil
+	}
+}
+
+// WithDialTimeout bounds the time spent establishing a new connection
+// by the client transport for the current request, unlike the context
+// deadline that bounds the whole request. See [WithClient] for the transport
+// options.
+func WithDialTimeout(d time.Duration) Option {
+	return func(params *doParams) error {
+		params.transport.dialTimeout = some(d)
+		return nil
+	}
+}
+
+// WithLocalAddr binds the connections established by the client transport
+// for the current request to the given local IP address, e.g., to pin
+// the source address on a multi-homed host. The dialer of the transport
+// is replaced by the one with the default settings. If the IP address
+// cannot be parsed, it returns an error. See [WithClient] for the transport
+// options.
+func WithLocalAddr(ip string) Option {
+	return func(params *doParams) error {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return fmt.Errorf("invalid local address: %w", err)
+		}
+
+		params.transport.localAddr = some(addr.Unmap())
+
+		return nil
+	}
+}
+
+// WithUnixSocket connects to the Unix domain socket at the given path instead
+// of the host of the URL, e.g., to talk to the Docker or systemd API, so
+// the URL is only used for the path and the Host header, e.g.,
+// "http://docker/v1.45/containers/json". The dialer of the transport is
+// replaced, taking precedence over [WithLocalAddr], and the proxy is not used.
+// See [WithClient] for the transport options.
+func WithUnixSocket(path string) Option {
+	return func(params *doParams) error {
+		if path == "" {
+			return errors.New("unix socket path is empty")
+		}
+
+		params.transport.unixSocket = some(path)
+		return nil
+	}
+}
+
+// WithResponseHeaderTimeout sets [net/http.Transport.ResponseHeaderTimeout]
+// of the client transport for the current request, i.e., the time to wait
+// for the response headers after the request is written. See [WithClient]
+// for the transport options.
+func WithResponseHeaderTimeout(d time.Duration) Option {
+	return func(params *doParams) error {
+		params.transport.responseHeaderTimeout = some(d)
+		return nil
+	}
+}
+
+// WithBodyReadTimeout bounds the total time spent reading the response body
+// since receiving the response headers, e.g., to defend against a server
+// that sends the headers quickly and then trickles the body. After
+// the timeout elapses, the body is closed, and reading it, e.g., by decoding,
+// fails with [TimeoutError] wrapping [ErrBodyReadTimeout]. Unlike the context
+// deadline, the time to establish the connection and to wait for the headers
+// is not counted. A non-positive timeout means no limit.
+func WithBodyReadTimeout(d time.Duration) Option {
+	return func(params *doParams) error {
+		params.bodyReadTimeout = d
+		return nil
+	}
+}
+
+// WithDisableKeepAlives sets [net/http.Transport.DisableKeepAlives] of the
+// client transport for the current request, so each connection is used
+// for a single request. See [WithClient] for the transport options.
+func WithDisableKeepAlives() Option {
+	return func(params *doParams) error {
+		params.transport.disableKeepAlives = some(true)
+		return nil
+	}
+}
+
+// WithSameHostRedirects follows the redirects only if they stay on the host
+// of the original request, including the port, e.g., when the redirect
+// targets are untrusted. Otherwise, it causes the [ErrCrossHostRedirect]
+// error. The redirect policy of the client, if any, is still applied,
+// but the client itself is not changed.
+func WithSameHostRedirects() Option {
+	return func(params *doParams) error {
+		params.sameHost = true
+		return nil
+	}
+}
+
+// WithFaultInjection injects the faults configured by [FaultConfig] into each
+// attempt to send the request, e.g., to test the retry and fallback logic:
+// the added latency, the transport errors, and the synthetic responses passed
+// to the handlers as the real ones. The faults are injected only if
+// [FaultConfig.Enabled] is set, otherwise the option has no effect.
+// The client itself is not changed.
+func WithFaultInjection(config FaultConfig) Option {
+	return func(params *doParams) error {
+		params.faults = config
+		return nil
+	}
+}
+
+// WithHedge sends a hedged copy of the request if the response headers
+// have not been received within the given delay, e.g., to cut the tail latency
+// of idempotent reads, and returns the first successful response, canceling
+// the other attempt via its context. If the first attempt fails before
+// the delay, the error is returned without hedging. Only the safe methods,
+// e.g., [GET], can be hedged, otherwise it causes the [ErrHedgeUnsafeMethod]
+// error. A non-positive delay disables hedging.
+func WithHedge(delay time.Duration) Option {
+	return func(params *doParams) error {
+		params.hedgeDelay = delay
+		return nil
+	}
+}
+
+// WithForceHTTP1 forces HTTP/1.1 by disabling HTTP/2 in the clone
+// of the client transport for the current request, e.g., for broken
+// middleboxes. See [WithClient] for the transport options.
+func WithForceHTTP1() Option {
+	return func(params *doParams) error {
+		params.transport.forceHTTP1 = some(true)
+		return nil
+	}
+}
+
+// WithHTTP2PriorKnowledge sends the request over cleartext HTTP/2 without
+// upgrading the connection (h2c with prior knowledge), e.g., for gRPC
+// gateways. The HTTP/2 transport is derived from the client transport,
+// preserving its dialer. It is only for URLs with the http scheme.
+// See [WithClient] for the transport options.
+func WithHTTP2PriorKnowledge() Option {
+	return func(params *doParams) error {
+		params.transport.http2PriorKnowledge = some(true)
+		return nil
+	}
+}
+
+// WithCloseConnection sets [net/http.Request.Close], so the connection
+// is closed after the response is read instead of being reused. Unlike
+// [WithDisableKeepAlives], it does not require cloning the client transport.
+func WithCloseConnection() Option {
+	return func(params *doParams) error {
+		params.closeConn = true
+		return nil
+	}
+}
+
+// WithURLPaths appends the given paths separated by '/' to the URL. Note that
+// the resulting URL is not escaped.
+func WithURLPaths(paths ...string) Option {
+	return func(params *doParams) error {
+		return params.urlBuilder.appendPaths(paths...)
+	}
+}
+
+// WithQuery adds a properly escaped query string encoded from the given data.
+// If the same key is added by several query options, it causes
+// the [ErrDuplicateQueryKeys] error, unless [WithAllowDuplicateQueryKeys]
+// is set. The data is encoded by [github.com/google/go-querystring/query.Values],
+// unless [WithQueryEncoder] is set.
+func WithQuery(data any) Option {
+	return func(params *doParams) error {
+		params.urlBuilder.deferQuery(data, false)
+		return nil
+	}
+}
+
+// WithQueryIndexed works like [WithQuery], but serializes the slices encoded
+// with the brackets option, e.g., `url:"ids,brackets"`, as indexed brackets,
+// i.e., "ids[0]=1&ids[1]=2" instead of "ids[]=1&ids[]=2", as some backends
+// require.
+func WithQueryIndexed(data any) Option {
+	return func(params *doParams) error {
+		params.urlBuilder.deferQuery(data, true)
+		return nil
+	}
+}
+
+// WithQueryEncoder sets the given [QueryEncoder] to encode the data of all
+// [WithQuery] options regardless of their order, e.g., to serialize custom
+// types in a special way.
+func WithQueryEncoder(encoder QueryEncoder) Option {
+	return func(params *doParams) error {
+		params.urlBuilder.encoder = encoder
+		return nil
+	}
+}
+
+// WithQuerySkipEmpty drops the keys with the empty string values from
+// the query strings encoded by all [WithQuery], [WithQueryIndexed], and
+// [WithQueryFromMap] options regardless of their order, as if each field had
+// the omitempty option, e.g., to omit the optional filters instead of sending
+// "field=". A key with several values keeps the non-empty ones. The query
+// left without keys is omitted. Nil values of [WithQueryFromMap] are skipped
+// anyway.
+func WithQuerySkipEmpty() Option {
+	return func(params *doParams) error {
+		params.urlBuilder.skipEmpty = true
+		return nil
+	}
+}
+
+// WithQueryArrayFormat sets the format of the query keys with several values
+// or the empty brackets, e.g., "ids[]", encoded by all [WithQuery],
+// [WithQueryIndexed], and [WithQueryFromMap] options regardless of their order,
+// so the struct tags need not be changed per API, e.g., [ArrayFormatComma]
+// turns "ids=1&ids=2" into "ids=1,2". The keys are sorted. It does not apply
+// to [WithQueryArray].
+func WithQueryArrayFormat(format ArrayFormat) Option {
+	return func(params *doParams) error {
+		if format < ArrayFormatRepeat || format > ArrayFormatIndex {
+			return fmt.Errorf("invalid array format %d", format)
+		}
+
+		params.urlBuilder.arrayFormat = format
+		return nil
+	}
+}
+
+// WithQueryFromMap adds a properly escaped query string encoded from the given
+// map. Each value is converted to a string: integers and floats in decimal,
+// booleans as true or false, and [time.Time] in RFC 3339 format. Nil values
+// are skipped, and slices expand into repeated keys. The keys are sorted.
+func WithQueryFromMap(m map[string]any) Option {
+	return func(params *doParams) error {
+		return params.urlBuilder.appendQueryFromMap(m)
+	}
+}
+
+// WithQueryArray adds the given key once with the given values joined by
+// the given delimiter, e.g., "ids=1,2,3" for the "," delimiter or
+// "ids=1%202%203" for the " " one, as the APIs described by OpenAPI often
+// expect, instead of the repeated keys. The values are escaped; the delimiter
+// is escaped only if it is not allowed in the query, e.g., the space.
+// Nothing is added if there are no values.
+func WithQueryArray(key string, values []string, delim string) Option {
+	return func(params *doParams) error {
+		params.urlBuilder.appendQueryArray(key, values, delim)
+		return nil
+	}
+}
+
+// WithAllowDuplicateQueryKeys allows the same key to be added by several
+// query options, e.g., [WithQuery] and [WithQueryFromMap]. All the values
+// are kept in the order of the options.
+func WithAllowDuplicateQueryKeys() Option {
+	return func(params *doParams) error {
+		params.urlBuilder.allowDuplicateQueryKeys = true
+		return nil
+	}
+}
+
+// WithAllowedSchemes sets the URL schemes that are allowed for the current
+// request, overwriting the default http and https ones. The schemes are
+// compared case-insensitively.
+func WithAllowedSchemes(scheme string, schemes ...string) Option {
+	return func(params *doParams) error {
+		params.urlValidator.allowedSchemes = append([]string{scheme}, schemes...)
+		return nil
+	}
+}
+
+// WithBaseURLCheck validates the base URL given to [Do] before the paths
+// and queries are appended to it, so the error points at the base URL.
+func WithBaseURLCheck() Option {
+	return func(params *doParams) error {
+		params.urlValidator.checkBase = true
+		return nil
+	}
+}
+
+// WithMaxURLLength limits the length of the URL that the request is sent to,
+// i.e., with the paths and queries appended, to the given number of bytes,
+// e.g., to fail before a gateway responds with an opaque 414 status code.
+// If the URL is too long, it causes the [InvalidURLError] error with
+// the [URLPartLength] part. A non-positive value means no limit, overriding
+// the one set by [SetDefaultMaxURLLength].
+func WithMaxURLLength(n int) Option {
+	return func(params *doParams) error {
+		params.maxURLLength = some(n)
+		return nil
+	}
+}
+
+// WithFinalURL stores the URL that the request is sent to, i.e., the URL given
+// to [Do] with the paths and queries appended, to the given string,
+// e.g., to log the signed or templated URL. See also [BuildURL].
+func WithFinalURL(url *string) Option {
+	return func(params *doParams) error {
+		params.finalURL = url
+		return nil
+	}
+}
+
+// WithHeader sets the HTTP header with the given key canonicalized, overwriting
+// the previous one, if any. If the key is not a valid field name token,
+// it causes the [ErrInvalidHeaderKey] error, see [NewHeaderKey].
+func WithHeader(key HeaderKey, value string, appendMode ...HeaderAppendMode) Option {
+	return withHeader(key, value, withHeaderOptions{
+		isKeyCanonicalized: false,
+		doesAddValueToEnd:  optionalBool(appendMode...),
+	})
+}
+
+// WithMaxHeaderBytes limits the size of the request header serialized
+// as "Key: value\r\n" lines, including the headers added by the handlers
+// set by [WithHandlerBeforeResponse], to the given number of bytes, e.g., to fail
+// before a gateway responds with an opaque 431 status code. If the header
+// is too large, it causes the [ErrHeaderTooLarge] error naming the largest
+// header. The headers added by the transport, e.g., User-Agent, are not
+// counted. A non-positive value means no limit, overriding the one set by
+// [SetDefaultMaxHeaderBytes].
+func WithMaxHeaderBytes(n int) Option {
+	return func(params *doParams) error {
+		params.maxHeaderBytes = some(n)
+		return nil
+	}
+}
+
+// WithContentType sets the HTTP Content-Type representation header, overwriting
+// the previous one, if any. The explicit header takes precedence over
+// the content type set by the body options, e.g., [WithJSON], regardless
+// of the order of the options.
+func WithContentType(value string, appendMode ...HeaderAppendMode) Option {
+	return withHeader(HeaderContentType, value, withHeaderOptions{
+		isKeyCanonicalized: true,
+		doesAddValueToEnd:  optionalBool(appendMode...),
+	})
+}
+
+// WithAccept sets the HTTP Accept request header, overwriting the previous one,
+// if any.
+func WithAccept(value string, appendMode ...HeaderAppendMode) Option {
+	return withHeader(HeaderAccept, value, withHeaderOptions{
+		isKeyCanonicalized: true,
+		doesAddValueToEnd:  optionalBool(appendMode...),
+	})
+}
+
+// WithAutoAccept sets the HTTP Accept request header to the media type
+// decoded by the handler for [OKStatuses], e.g., "application/json" for
+// [OKStatuses.ToJSON], "application/xml" for [OKStatuses.ToXML], or "text/csv"
+// for [OKStatuses.ToCSV], so the server does not respond with another one.
+// The explicit Accept header takes precedence regardless of the order
+// of the options. The handlers with a custom [Decoder] set no media type.
+func WithAutoAccept() Option {
+	return func(params *doParams) error {
+		params.autoAccept = true
+		return nil
+	}
+}
+
+// WithAcceptLanguage sets the HTTP Accept-Language request header, overwriting
+// the previous one, if any.
+func WithAcceptLanguage(value string, appendMode ...HeaderAppendMode) Option {
+	return withHeader(HeaderAcceptLanguage, value, withHeaderOptions{
+		isKeyCanonicalized: true,
+		doesAddValueToEnd:  optionalBool(appendMode...),
+	})
+}
+
+// WithAcceptCharset sets the HTTP Accept-Charset request header, overwriting
+// the previous one, if any.
+func WithAcceptCharset(value string, appendMode ...HeaderAppendMode) Option {
+	return withHeader(HeaderAcceptCharset, value, withHeaderOptions{
+		isKeyCanonicalized: true,
+		doesAddValueToEnd:  optionalBool(appendMode...),
+	})
+}
+
+// WithAcceptEncoding sets the HTTP Accept-Encoding request header, overwriting
+// the previous one, if any. Note that [net/http.Transport] decompresses
+// the gzip-encoded response body transparently only if the header is not set,
+// so the handlers receive the body as encoded by the server.
+func WithAcceptEncoding(value string, appendMode ...HeaderAppendMode) Option {
+	return withHeader(HeaderAcceptEncoding, value, withHeaderOptions{
+		isKeyCanonicalized: true,
+		doesAddValueToEnd:  optionalBool(appendMode...),
+	})
+}
+
+// WithAuth sets the HTTP Authorization request header with the given value.
+func WithAuth(value string, appendMode ...HeaderAppendMode) Option {
+	return withHeader(HeaderAuthorization, value, withHeaderOptions{
+		isKeyCanonicalized: true,
+		doesAddValueToEnd:  optionalBool(appendMode...),
+	})
+}
+
+// WithBasicAuth sets the HTTP Authorization header to use HTTP Basic Authentication
+// with the provided username and password.
+func WithBasicAuth(username, password string) Option {
+	enc := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return WithAuth("Basic " + enc)
+}
+
+// BasicAuthFunc returns the username and password for HTTP Basic
+// Authentication.
+type BasicAuthFunc func(ctx context.Context) (username, password string, err error)
+
+// WithBasicAuthFunc sets the HTTP Authorization header to use HTTP Basic
+// Authentication with the username and password returned by the given
+// function right before each attempt to send the request, so the freshest
+// credentials are used. The function is called with the request context.
+func WithBasicAuthFunc(fn BasicAuthFunc) Option {
+	return WithHandlerBeforeResponse(func(req *http.Request) error {
+		username, password, err := fn(req.Context())
+		if err != nil {
+			return err
+		}
+
+		req.SetBasicAuth(username, password)
+
+		return nil
+	})
+}
+
+// WithExpectContinue sets the HTTP Expect request header to "100-continue",
+// so the body is sent only after the server responds with the 100 Continue
+// status, e.g., to not upload a large body when the server rejects
+// the request because of failed authorization. The client transport is cloned
+// with [net/http.Transport.ExpectContinueTimeout] set to the given timeout,
+// after which the body is sent anyway.
+//
+// The client transport must be [net/http.Transport].
+func WithExpectContinue(timeout time.Duration) Option {
+	return optparams.Join[doParams](
+		withHeader(HeaderExpect, "100-continue", withHeaderOptions{
+			isKeyCanonicalized: true,
+		}),
+		func(params *doParams) error {
+			params.transport.expectContinueTimeout = some(timeout)
+			return nil
+		},
+	)
+}
+
+// WithRange sets the HTTP Range request header to request the bytes
+// from start to end inclusive. If end is negative, the range is open-ended,
+// i.e., up to the end of the content. See [WithPartialContent] and
+// [DownloadRanged].
+func WithRange(start, end int64) Option {
+	return func(params *doParams) error {
+		if start < 0 || (end >= 0 && end < start) {
+			return fmt.Errorf("invalid range: %d-%d", start, end)
+		}
+
+		value := "bytes=" + strconv.FormatInt(start, 10) + "-"
+		if end >= 0 {
+			value += strconv.FormatInt(end, 10)
+		}
+
+		return withHeader(HeaderRange, value, withHeaderOptions{isKeyCanonicalized: true})(params)
+	}
+}
+
+// WithRequestID sets the HTTP X-Request-Id request header with the given
+// value. The same ID is sent on each attempt of the request.
+func WithRequestID(id string) Option {
+	return withHeader(HeaderXRequestID, id, withHeaderOptions{
+		isKeyCanonicalized: true,
+	})
+}
+
+// WithGeneratedRequestID sets the HTTP X-Request-Id request header with
+// a random ID and writes the ID to the value pointed to by out, so it can be
+// used, e.g., to correlate logs. The same ID is sent on each attempt
+// of the request.
+func WithGeneratedRequestID(out *string) Option {
+	return func(params *doParams) error {
+		id, err := generateRequestID()
+		if err != nil {
+			return err
+		}
+
+		if out != nil {
+			*out = id
+		}
+
+		return WithRequestID(id)(params)
+	}
+}
+
+func generateRequestID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b[:]), nil
+}
+
+// ErrBodyAlreadyExists is returned when several body options are given.
+// The returned error names both options, e.g., "body already exists: set
+// by WithJSON, cannot apply WithBytes".
+var ErrBodyAlreadyExists = errors.New("body already exists")
+
+// WithBody adds the given data as the body content. If the body is already set,
+// it causes the [ErrBodyAlreadyExists] error.
+func WithBody(data io.Reader) Option {
+	return func(params *doParams) error {
+		if err := params.checkNoBody("WithBody"); err != nil {
+			return err
+		}
+
+		params.body = data
+		params.bodySource = "WithBody"
+
+		return nil
+	}
+}
+
+// WithBodyFromFile opens the file by the given path and streams its content
+// as the body. Content-Length is set from the file size if the file is
+// a regular one, and the content type is set by the file extension, see
+// [mime.TypeByExtension], unless the Content-Type header is already set.
+// The file is closed after the request, even if it fails before sending.
+// Since [net/http.Client.Do] closes the body, the request cannot be retried,
+// as with any [io.Closer] body given to [WithBody], unless [WithBufferBody]
+// is set. If the body is already set, it causes the [ErrBodyAlreadyExists]
+// error.
+func WithBodyFromFile(path string) Option {
+	return func(params *doParams) error {
+		if err := params.checkNoBody("WithBodyFromFile"); err != nil {
+			return err
+		}
+
+		file, err := os.Open(filepath.Clean(path))
+		if err != nil {
+			return err
+		}
+
+		info, err := file.Stat()
+		if err != nil {
+			return errors.Join(err, file.Close())
+		}
+
+		params.body = file
+		params.bodyFile = file
+		params.bodyLength = -1
+		if info.Mode().IsRegular() {
+			params.bodyLength = info.Size()
+		}
+		params.bodySource = "WithBodyFromFile"
+		params.bodyType = mime.TypeByExtension(filepath.Ext(path))
+
+		return nil
+	}
+}
+
+// WithChunkedBody adds the given data as the body content and forces
+// the chunked transfer encoding over HTTP/1.1 even if the length of the data
+// is known, e.g., for the streaming uploads that require it. HTTP/2 has its own
+// framing, so the encoding is not applied. If the body is already set, it
+// causes the [ErrBodyAlreadyExists] error, e.g., the length of the body set
+// by [WithBodyFromFile] cannot be combined with the chunked encoding.
+func WithChunkedBody(data io.Reader) Option {
+	return func(params *doParams) error {
+		if err := params.checkNoBody("WithChunkedBody"); err != nil {
+			return err
+		}
+
+		params.body = data
+		params.bodySource = "WithChunkedBody"
+		params.chunked = true
+
+		return nil
+	}
+}
+
+// WithReplaceBody sets the given data as the body content, replacing the body
+// set by the previous options, if any, without the [ErrBodyAlreadyExists]
+// error, e.g., to override the body set by a higher-level helper on purpose.
+// The content type set by the replaced body option is dropped as well.
+// The following body options cause the [ErrBodyAlreadyExists] error as usual.
+func WithReplaceBody(data io.Reader) Option {
+	return func(params *doParams) error {
+		params.closeBodyFile()
+		params.body = data
+		params.bodyWriter = nil
+		params.chunked = false
+		params.bodySource = "WithReplaceBody"
+		params.bodyType = ""
+
+		return nil
+	}
+}
+
+// WithBodyWriterFunc adds the body content written by the given function
+// and sets the given content type. The function runs in a separate goroutine
+// for each attempt, writing to a pipe while the transport reads from it,
+// so the content is streamed without holding it in memory. The error returned
+// by the function is returned by [Do]. The writes fail after the request
+// has been completed or failed, e.g., if the connection is refused, so
+// the function must return on a write error. If the body is already set,
+// it causes the [ErrBodyAlreadyExists] error.
+func WithBodyWriterFunc(fn BodyWriterFunc, contentType string) Option {
+	return func(params *doParams) error {
+		if err := params.checkNoBody("WithBodyWriterFunc"); err != nil {
+			return err
+		}
+
+		params.bodyWriter = fn
+		params.bodySource = "WithBodyWriterFunc"
+		params.bodyType = contentType
+
+		return nil
+	}
+}
+
+// WithBufferBody reads the entire body content set by [WithBody] into memory
+// once before sending the request, closing the original body if it is
+// [io.Closer]. The buffered body is replayed by retries, e.g., after
+// [RateLimitStatuses.Cooldown], and redirects, trading memory for retry
+// safety. It has no effect on the body written by [WithBodyWriterFunc],
+// since the function is called for each attempt.
+func WithBufferBody() Option {
+	return func(params *doParams) error {
+		params.bufferBody = true
+		return nil
+	}
+}
+
+// WithBytes adds the given bytes as the body content. If the body is already
+// set, it causes the [ErrBodyAlreadyExists] error.
+func WithBytes(data []byte) Option {
+	return func(params *doParams) error {
+		if err := params.checkNoBody("WithBytes"); err != nil {
+			return err
+		}
+
+		params.body = bytes.NewReader(data)
+		params.bodySource = "WithBytes"
+
+		return nil
+	}
+}
+
+// WithTextPlain adds the given text as the body content and sets the content
+// type as "text/plain". If the body is already set, it causes
+// the [ErrBodyAlreadyExists] error.
+func WithTextPlain(data string) Option {
+	return func(params *doParams) error {
+		if err := params.checkNoBody("WithTextPlain"); err != nil {
+			return err
+		}
+
+		params.body = strings.NewReader(data)
+		params.bodySource = "WithTextPlain"
+		params.bodyType = string(ContentTextPlain)
+
+		return nil
+	}
+}
+
+// WithEncoded encodes the given data using [Encoder] as the body content
+// and sets the given content type. If the body is already set, it causes
+// the [ErrBodyAlreadyExists] error.
+func WithEncoded(data any, encoder Encoder, contentType string) Option {
+	return WithEncodedNamed("WithEncoded", data, encoder, contentType)
+}
+
+// WithEncodedNamed works like [WithEncoded], but names the given option
+// in the [ErrBodyAlreadyExists] error instead of WithEncoded, e.g., to build
+// a body option for another format on top of it, so the error names
+// the option that the user called.
+func WithEncodedNamed(option string, data any, encoder Encoder, contentType string) Option {
+	return func(params *doParams) error {
+		if err := params.checkNoBody(option); err != nil {
+			return err
+		}
+
+		var buffer bytes.Buffer
+		if err := encoder(&buffer, data); err != nil {
+			return err
+		}
+		params.body = bytes.NewReader(buffer.Bytes())
+		params.bodySource = option
+		params.bodyType = contentType
+
+		return nil
+	}
+}
+
+// WithJSON encodes the given data in JSON format as the body content and sets
+// the content type as "application/json". If the body is already set, it causes
+// the [ErrBodyAlreadyExists] error.
+func WithJSON(data any) Option {
+	return WithEncodedNamed("WithJSON", data, jsonEncoder, string(ContentJSON))
+}
+
+// WithXML encodes the given data in XML format as the body content and sets
+// the content type as "application/xml". If the body is already set, it causes
+// the [ErrBodyAlreadyExists] error.
+func WithXML(data any) Option {
+	return WithEncodedNamed("WithXML", data, xmlEncoder, string(ContentXML))
+}
+
+// ErrNotMarshaler is returned by [WithMarshaledBody] when the given value
+// implements none of the supported interfaces.
+var ErrNotMarshaler = errors.New(
+	"value implements neither io.WriterTo, json.Marshaler, nor encoding.TextMarshaler",
+)
+
+// WithMarshaledBody adds the given value rendered by itself as the body content
+// and sets the given content type. The most specific interface is used:
+//   - [io.WriterTo] streams the content like [WithBodyWriterFunc], calling
+//     WriteTo for each attempt, so it must write the same content each time;
+//   - [encoding/json.Marshaler];
+//   - [encoding.TextMarshaler].
+//
+// If the value implements none of them, it causes the [ErrNotMarshaler] error.
+// If the body is already set, it causes the [ErrBodyAlreadyExists] error.
+func WithMarshaledBody(v any, contentType string) Option {
+	return func(params *doParams) error {
+		if err := params.checkNoBody("WithMarshaledBody"); err != nil {
+			return err
+		}
+
+		switch m := v.(type) {
+		case io.WriterTo:
+			params.bodyWriter = func(w io.Writer) error {
+				_, err := m.WriteTo(w)
+				return err
+			}
+		case json.Marshaler:
+			data, err := m.MarshalJSON()
+			if err != nil {
+				return err
+			}
+			params.body = bytes.NewReader(data)
+		case encoding.TextMarshaler:
+			data, err := m.MarshalText()
+			if err != nil {
+				return err
+			}
+			params.body = bytes.NewReader(data)
+		default:
+			return fmt.Errorf("%w: %T", ErrNotMarshaler, v)
+		}
+
+		params.bodySource = "WithMarshaledBody"
+		params.bodyType = contentType
+
+		return nil
+	}
+}
+
+// WithContentMD5 sets the HTTP Content-MD5 header with the base64-encoded MD5
+// checksum of the body. The body must be [io.ReadSeeker], e.g., set by
+// [WithBytes], so it can be rewound after computing the checksum, otherwise
+// it causes the [ErrBodyNotSeekable] error. The option can be given before
+// or after the body option.
+func WithContentMD5() Option {
+	return func(params *doParams) error {
+		params.checksums = append(params.checksums, newContentMD5Checksum())
+		return nil
+	}
+}
+
+// WithDigest sets the HTTP Digest header with the base64-encoded checksum
+// of the body computed by the given algorithm: "MD5", "SHA-256", or "SHA-512",
+// e.g., "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=". The body must
+// be [io.ReadSeeker], e.g., set by [WithBytes], so it can be rewound after
+// computing the checksum, otherwise it causes the [ErrBodyNotSeekable] error.
+// The option can be given before or after the body option.
+func WithDigest(algorithm string) Option {
+	return func(params *doParams) error {
+		checksum, err := newDigestChecksum(algorithm)
+		if err != nil {
+			return err
+		}
+
+		params.checksums = append(params.checksums, checksum)
+
+		return nil
+	}
+}
+
+// WithMultipartForm returns [MultipartFormBuilder] to add multipart sections
+// sequentially before calling the [MultipartFormBuilder.Body] method.
+func WithMultipartForm() *MultipartFormBuilder {
+	var b MultipartFormBuilder
+	b.mw = multipart.NewWriter(&b.buf)
+	return &b
+}
+
+// WithTrailer declares the given HTTP trailer names in
+// [net/http.Request.Trailer]. The trailers are sent after the body only if
+// the body is sent chunked, i.e., the length of the body is unknown.
+// The trailer values can be set by the body reader while it is being read,
+// e.g., by the reader captured in the handler added by
+// [WithHandlerBeforeResponse] having access to [net/http.Request.Trailer].
+func WithTrailer(keys ...HeaderKey) Option {
+	return func(params *doParams) error {
+		for _, key := range keys {
+			params.trailers = append(params.trailers,
+				textproto.CanonicalMIMEHeaderKey(string(key)),
+			)
+		}
+
+		return nil
+	}
+}
+
+// WithResponseTrailer adds the given handler to call it with
+// [net/http.Response.Trailer] of the successful HTTP response. Note that
+// the trailers are only populated after the body is fully read, so the rest
+// of the body that is not consumed by the handler added by [WithOK]
+// is discarded before calling the handler.
+func WithResponseTrailer(handler TrailerHandler) Option {
+	return func(params *doParams) error {
+		params.handler.trailerResponse = append(params.handler.trailerResponse, handler)
+		return nil
+	}
+}
+
+// WithAllowCustomMethod allows the HTTP methods other than the standard ones,
+// e.g., the WebDAV PROPFIND method. Without it, an unknown method, e.g.,
+// a typo, causes the [ErrInvalidHTTPMethod] error. The method still must be
+// a valid token.
+func WithAllowCustomMethod() Option {
+	return func(params *doParams) error {
+		params.customMethod = true
+		return nil
+	}
+}
+
+// WithMethodOverride sends the [PUT], [PATCH], and [DELETE] requests as [POST]
+// with the HTTP X-HTTP-Method-Override header set to the original method,
+// e.g., for firewalls blocking these methods. Other methods are sent as is.
+//
+// Note that on the wire the request is POST, which is neither safe nor
+// idempotent, so intermediaries, e.g., caches and proxies, treat even PUT
+// and DELETE as non-idempotent. The server must honor the header to preserve
+// the semantics of the original method.
+func WithMethodOverride() Option {
+	return func(params *doParams) error {
+		params.override = true
+		return nil
+	}
+}
+
+// WithHandlerBeforeResponse adds the given handler to call it right before
+// the sending HTTP request.
+func WithHandlerBeforeResponse(handler BeforeResponseHandler) Option {
+	return func(params *doParams) error {
+		params.handler.beforeResponse = append(params.handler.beforeResponse, handler)
+		return nil
+	}
+}
+
+// RequestMutator adjusts [net/http.Request] right before sending it.
+type RequestMutator func(*http.Request)
+
+// WithRequestMutator adds the given mutator to call it right before
+// the sending HTTP request, along with the handlers added by
+// [WithHandlerBeforeResponse] in the order of the options. Unlike them,
+// the mutator cannot fail, e.g.:
+//
+//	rqx.WithRequestMutator(func(req *http.Request) { req.Close = true })
+func WithRequestMutator(mutator RequestMutator) Option {
+	return WithHandlerBeforeResponse(func(req *http.Request) error {
+		mutator(req)
+		return nil
+	})
+}
+
+// WithHandlerAfterResponse adds the given handler to call it immediately after
+// receiving non-nil [net/http.Response].
+func WithHandlerAfterResponse(handler AfterResponseHandler) Option {
+	return func(params *doParams) error {
+		params.handler.afterResponse = append(params.handler.afterResponse, handler)
+		return nil
+	}
+}
+
+// WithHeadersInto populates the fields of the struct pointed to by the given
+// destination from the response header immediately after receiving non-nil
+// [net/http.Response], like [WithQuery] does for the request query.
+// The fields are matched by the "header" tag, e.g., `header:"X-Request-Id"`.
+//
+// The supported field types are string, signed and unsigned integers,
+// [time.Time] parsed by [net/http.ParseTime], and []string for multi-valued
+// headers. The first header value is used for a non-slice field. Missing
+// headers leave the zero value. An unparsable value causes
+// the [HeaderFieldError] error.
+func WithHeadersInto(dest any) Option {
+	return func(params *doParams) error {
+		value := reflect.ValueOf(dest)
+		if value.Kind() != reflect.Pointer || value.IsNil() ||
+			value.Elem().Kind() != reflect.Struct {
+			return errHeadersDest
+		}
+
+		return WithHandlerAfterResponse(func(resp *http.Response) error {
+			return headersInto(value, resp.Header)
+		})(params)
+	}
+}
+
+// WithAllowInto stores the methods of the Allow header of the response
+// to the value pointed to by the given methods immediately after receiving
+// non-nil [net/http.Response], e.g., to probe the capabilities by [Options].
+// The comma-separated methods are uppercased and deduplicated; the empty
+// or missing header results in nil. An invalid method causes an error.
+func WithAllowInto(methods *[]HTTPMethod) Option {
+	return func(params *doParams) error {
+		if methods == nil {
+			return errors.New("methods is nil")
+		}
+
+		return WithHandlerAfterResponse(func(resp *http.Response) error {
+			allow, err := parseMethodList(resp.Header, HeaderAllow)
+			if err != nil {
+				return err
+			}
+
+			*methods = allow
+
+			return nil
+		})(params)
+	}
+}
+
+// WithCORSInto stores the CORS headers of the response, e.g., of a preflight
+// request, to the value pointed to by the given cors immediately after
+// receiving non-nil [net/http.Response]. The lists are parsed like
+// by [WithAllowInto]; the header keys are canonicalized. An invalid value
+// causes an error.
+func WithCORSInto(cors *CORS) Option {
+	return func(params *doParams) error {
+		if cors == nil {
+			return errors.New("cors is nil")
+		}
+
+		return WithHandlerAfterResponse(func(resp *http.Response) error {
+			parsed, err := parseCORS(resp.Header)
+			if err != nil {
+				return err
+			}
+
+			*cors = parsed
+
+			return nil
+		})(params)
+	}
+}
+
+// WithResponseInfo stores the details of the response, e.g., the negotiated
+// protocol, to the value pointed to by the given info immediately after
+// receiving non-nil [net/http.Response].
+func WithResponseInfo(info *ResponseInfo) Option {
+	return func(params *doParams) error {
+		if info == nil {
+			return errors.New("response info is nil")
+		}
+
+		return WithHandlerAfterResponse(func(resp *http.Response) error {
+			*info = newResponseInfo(resp)
+			return nil
+		})(params)
+	}
+}
+
+// WithRateLimitInfoInto stores [RateLimitInfo] parsed from the header
+// of every response, not only the one with the rate limit status, to the value
+// pointed to by the given info, e.g., to slow down before the rate limit
+// is reached, see [ParseRateLimitHeaders].
+func WithRateLimitInfoInto(info *RateLimitInfo) Option {
+	return func(params *doParams) error {
+		if info == nil {
+			return errors.New("rate limit info is nil")
+		}
+
+		return WithHandlerAfterResponse(func(resp *http.Response) error {
+			*info = ParseRateLimitHeaders(resp.Header)
+			return nil
+		})(params)
+	}
+}
+
+// WithOnStatus adds the given handler to call it when the HTTP status code
+// of the response matches the given one. The handler is not terminal: it is
+// called after the handlers added by [WithHandlerAfterResponse] and before
+// the ones added by [WithOK] and [WithError]. The handler receives a copy
+// of the response whose body can be read without consuming the body
+// for the following handlers. The body is buffered only as far as
+// the handler reads it, up to 1 MiB; reading further consumes the body,
+// and the following handlers fail to read it.
+func WithOnStatus(status int, handler AfterResponseHandler) Option {
+	return func(params *doParams) error {
+		params.handler.onStatus = append(params.handler.onStatus, statusHandler{
+			status: status,
+			handle: handler,
+		})
+
+		return nil
+	}
+}
+
+// WithValidateResponseBody adds the given validator to check the body
+// of the successful HTTP response before decoding it by the handler added
+// by [WithOK]. The validator receives a copy of the body, so the subsequent
+// decoding still works.
+func WithValidateResponseBody(validator BodyValidator) Option {
 	return func(params *doParams) error {
-		params.client = c
+		params.handler.bodyValidators = append(params.handler.bodyValidators, validator)
 		return nil
 	}
 }
 
-// WithURLPaths appends the given paths separated by '/' to the URL. Note that
-// the resulting URL is not escaped.
-func WithURLPaths(paths ...string) Option {
+// WithExpectContentType checks that the media type of the HTTP response with
+// a 2xx status code is one of the given ones, ignoring parameters, e.g.,
+// charset, and case, before the handler reads the body. The wildcards are
+// supported, e.g., "application/*" or "*/*". Otherwise, e.g., if the server
+// responds with an HTML page instead of JSON, it causes
+// the [UnexpectedContentTypeError] error instead of a confusing decoding one.
+func WithExpectContentType(mediaType ContentType, mediaTypes ...ContentType) Option {
+	expected := append([]ContentType{mediaType}, mediaTypes...)
+
+	return WithHandlerAfterResponse(func(resp *http.Response) error {
+		if !StatusClassSuccessful.Contains(resp.StatusCode) {
+			return nil
+		}
+
+		value := resp.Header.Get(string(HeaderContentType))
+
+		actual, _, err := mime.ParseMediaType(value)
+		if err == nil && slices.ContainsFunc(expected, func(pattern ContentType) bool {
+			return matchMediaType(string(pattern), actual)
+		}) {
+			return nil
+		}
+
+		return &UnexpectedContentTypeError{Expected: expected, Actual: value}
+	})
+}
+
+// matchMediaType reports whether the given media type matches the given
+// pattern, possibly with the wildcards, e.g., "application/*" or "*/*",
+// ignoring case.
+func matchMediaType(pattern, mediaType string) bool {
+	patternType, patternSubtype, _ := strings.Cut(pattern, "/")
+	actualType, actualSubtype, _ := strings.Cut(mediaType, "/")
+
+	return (patternType == "*" || strings.EqualFold(patternType, actualType)) &&
+		(patternSubtype == "*" || strings.EqualFold(patternSubtype, actualSubtype))
+}
+
+// WithExpectHeader checks that the given header of the HTTP response with
+// a 2xx status code satisfies the given predicate before the handler reads
+// the body. The predicate receives the first value of the header, or
+// the empty string if the header is missing. Otherwise, it causes
+// the [UnexpectedHeaderError] error.
+func WithExpectHeader(key HeaderKey, predicate func(value string) bool) Option {
+	return WithHandlerAfterResponse(func(resp *http.Response) error {
+		if !StatusClassSuccessful.Contains(resp.StatusCode) {
+			return nil
+		}
+
+		value := resp.Header.Get(string(key))
+		if predicate(value) {
+			return nil
+		}
+
+		return &UnexpectedHeaderError{Key: key, Actual: value}
+	})
+}
+
+// WithByteCounter adds the number of bytes of the request and response bodies
+// to the given [ByteCounter] while they are sent and read, so the counter
+// is complete after [Do] returns. The bytes are counted once regardless
+// of the handlers reading the response body, e.g., streaming or decoding it.
+func WithByteCounter(counter *ByteCounter) Option {
 	return func(params *doParams) error {
-		return params.urlBuilder.appendPaths(paths...)
+		params.byteCounter = counter
+		return nil
 	}
 }
 
-// WithQuery adds a properly escaped query string encoded from the given data.
-func WithQuery(data any) Option {
+// WithUploadProgress calls the given function with [UploadProgress] while
+// the client reads the request body, e.g., to show the progress of uploading
+// a large file. The total length is known for the bodies of known length,
+// e.g., set by [WithBytes] or [WithBodyFromFile], and -1 otherwise.
+//
+// To avoid the overhead on each read, the function is called once at least
+// the given number of bytes is read or the given interval has passed since
+// the previous call, and once the body is read; if neither is positive,
+// it is called on each read. The function is called by the client transport
+// in its goroutine. On retries, the progress starts over from zero with
+// the next attempt number.
+func WithUploadProgress(
+	report func(progress UploadProgress),
+	every int64,
+	interval time.Duration,
+) Option {
 	return func(params *doParams) error {
-		return params.urlBuilder.appendQuery(data)
+		if report == nil {
+			return errors.New("upload progress function is nil")
+		}
+
+		params.uploadProgress = &uploadProgress{report: report, every: every, interval: interval}
+		return nil
 	}
 }
 
-func WithHeader(key HeaderKey, value string, appendMode ...HeaderAppendMode) Option {
-	return withHeader(key, value, withHeaderOptions{
-		isKeyCanonicalized: false,
-		doesAddValueToEnd:  optionalBool(appendMode...),
-	})
+// WithTee writes a copy of the response body to the given writer while
+// the handlers read it, e.g., for audit logging, so the body is decoded
+// as usual. The unread rest of the body, if any, is written while it is
+// drained before closing, so the writer receives the full body of each
+// attempt regardless of the handling path, unless the rest is larger than
+// the limit set by [WithDrainOnClose]. A write error fails reading the body,
+// and it is returned by [Do] even if the handlers have not read the body,
+// see [WithStrictClose].
+func WithTee(w io.Writer) Option {
+	return func(params *doParams) error {
+		params.tee = w
+		return nil
+	}
 }
 
-// WithContentType sets the HTTP Content-Type representation header, overwriting
-// the previous one, if any.
-func WithContentType(value string, appendMode ...HeaderAppendMode) Option {
-	return withHeader(HeaderContentType, value, withHeaderOptions{
-		isKeyCanonicalized: true,
-		doesAddValueToEnd:  optionalBool(appendMode...),
-	})
-}
+// WithVerifyChecksum verifies the body of the HTTP response with a 2xx status
+// code against the given hex-encoded checksum, e.g., of a downloaded artifact.
+// The supported algorithms are "MD5", "SHA-256", and "SHA-512", ignoring
+// case and dashes. The body is hashed while it is read by the handler, and
+// the rest of the body is hashed before closing it, so the checksum is
+// verified even if the handler has not read the whole body. On mismatch,
+// [Do] returns the [ChecksumMismatchError] error.
+func WithVerifyChecksum(algorithm, expectedHex string) Option {
+	return func(params *doParams) error {
+		name, newHash, ok := lookupDigestAlgorithm(algorithm)
+		if !ok {
+			return fmt.Errorf("unsupported checksum algorithm %q", algorithm)
+		}
 
-// WithAccept sets the HTTP Accept request header, overwriting the previous one,
-// if any.
-func WithAccept(value string, appendMode ...HeaderAppendMode) Option {
-	return withHeader(HeaderAccept, value, withHeaderOptions{
-		isKeyCanonicalized: true,
-		doesAddValueToEnd:  optionalBool(appendMode...),
-	})
-}
+		expected, err := hex.DecodeString(expectedHex)
+		if err != nil {
+			return fmt.Errorf("invalid expected checksum: %w", err)
+		}
 
-// WithAuth sets the HTTP Authorization request header with the given value.
-func WithAuth(value string, appendMode ...HeaderAppendMode) Option {
-	return withHeader(HeaderAuthorization, value, withHeaderOptions{
-		isKeyCanonicalized: true,
-		doesAddValueToEnd:  optionalBool(appendMode...),
-	})
+		return WithHandlerAfterResponse(func(resp *http.Response) error {
+			if !StatusClassSuccessful.Contains(resp.StatusCode) {
+				return nil
+			}
+
+			resp.Body = &verifyingBody{
+				body:      resp.Body,
+				hash:      newHash(),
+				algorithm: name,
+				expected:  expected,
+			}
+
+			return nil
+		})(params)
+	}
 }
 
-// WithBasicAuth sets the HTTP Authorization header to use HTTP Basic Authentication
-// with the provided username and password.
-func WithBasicAuth(username, password string) Option {
-	enc := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
-	return WithAuth("Basic " + enc)
+// WithJSONUseNumber decodes the JSON numbers as [encoding/json.Number]
+// instead of float64 into the interface values, e.g., map[string]any, so
+// the 64-bit IDs are not corrupted, by all JSON handlers, e.g., added by
+// [OKStatuses.ToJSON] and [ErrorStatuses.ToJSON], regardless of the order
+// of the options.
+func WithJSONUseNumber() Option {
+	return func(params *doParams) error {
+		params.handler.jsonUseNumber = true
+		return nil
+	}
 }
 
-var ErrBodyAlreadyExists = errors.New("body already exists")
+// WithJSONInt64 converts the JSON numbers stored as [encoding/json.Number]
+// by [OKStatuses.ToMap] to int64 recursively where they fit, regardless
+// of the order of the options. The other numbers, e.g., 1.5 or the ones
+// exceeding int64, are kept as [encoding/json.Number].
+func WithJSONInt64() Option {
+	return func(params *doParams) error {
+		params.handler.jsonInt64 = true
+		return nil
+	}
+}
 
-// WithBody adds the given data as the body content. If the body is already set,
-// it causes the [ErrBodyAlreadyExists] error.
-func WithBody(data io.Reader) Option {
+// WithDecodeErrorContext wraps the error of decoding the response body
+// by the handlers, e.g., added by [OKStatuses.ToJSON] or [ErrorStatuses.ToJSON],
+// in [DecodeError] with at most the given number of the first bytes
+// of the body, e.g., to see what was received instead of the expected JSON.
+func WithDecodeErrorContext(prefixLength int) Option {
 	return func(params *doParams) error {
-		if params.body != nil {
-			return ErrBodyAlreadyExists
+		if prefixLength <= 0 {
+			return fmt.Errorf("body prefix length must be positive, got %d", prefixLength)
 		}
 
-		params.body = data
-
+		params.handler.decodePrefix = prefixLength
 		return nil
 	}
 }
 
-// WithBytes adds the given bytes as the body content. If the body is already
-// set, it causes the [ErrBodyAlreadyExists] error.
-func WithBytes(data []byte) Option {
+var errInvalidCSVDelimiter = errors.New("invalid CSV delimiter")
+
+// WithCSVDelimiter sets the field delimiter of the CSV response body decoded
+// by the handler added by [OKStatuses.ToCSV]. By default, a comma is used.
+// The delimiter must be a valid rune other than a quote, \r, or \n.
+func WithCSVDelimiter(delimiter rune) Option {
 	return func(params *doParams) error {
-		if params.body != nil {
-			return ErrBodyAlreadyExists
+		if delimiter == 0 || delimiter == '"' || delimiter == '\r' || delimiter == '\n' ||
+			!utf8.ValidRune(delimiter) || delimiter == utf8.RuneError {
+			return errInvalidCSVDelimiter
 		}
 
-		params.body = bytes.NewReader(data)
+		params.handler.csvDelimiter = delimiter
 
 		return nil
 	}
 }
 
-// WithTextPlain adds the given text as the body content and sets the content
-// type as "text/plain". If the body is already set, it causes
-// the [ErrBodyAlreadyExists] error.
-func WithTextPlain(data string) Option {
-	return optparams.Join[doParams](
-		func(params *doParams) error {
-			if params.body != nil {
-				return ErrBodyAlreadyExists
-			}
+// WithOK returns [OKStatuses] to add a handler for the successful HTTP response.
+// By default, [net/http.StatusOK] is used as the successful HTTP status code.
+func WithOK(statuses ...int) OKStatuses {
+	if len(statuses) == 0 {
+		return OKStatuses{codes: []int{http.StatusOK}}
+	}
 
-			params.body = strings.NewReader(data)
+	return OKStatuses{codes: statuses}
+}
 
+// WithExpectStatus discards the body of the HTTP response with the given
+// status code and reports success, see [OKStatuses.ToDiscard]. Any other
+// status code causes the [UnexpectedStatusError] error, unless it is matched
+// by the handlers added by [WithError] or [WithRateLimit], or handled by
+// [WithFailOnErrorStatus], regardless of the order of the options.
+func WithExpectStatus(status int) Option {
+	return optparams.Join[doParams](
+		WithOK(status).ToDiscard(),
+		func(params *doParams) error {
+			params.handler.hasErrorHandler = true
+			params.handler.expectedStatus = status
 			return nil
 		},
-		WithContentType(string(ContentTextPlain)),
 	)
 }
 
-// WithJSON encodes the given data in JSON format as the body content and sets
-// the content type as "application/json". If the body is already set, it causes
-// the [ErrBodyAlreadyExists] error.
-func WithJSON(data any) Option {
-	return optparams.Join[doParams](
-		func(params *doParams) error {
-			if params.body != nil {
-				return ErrBodyAlreadyExists
-			}
+// WithPartialContent returns [OKStatuses] to add a handler for the successful
+// HTTP response with [net/http.StatusOK] or [net/http.StatusPartialContent],
+// e.g., for the request with [WithRange].
+//
+// Note that the server may ignore the range and respond with the whole
+// content and the [net/http.StatusOK] status code, so to resume a download
+// into a file, check the status code in the handler and rewrite the whole
+// file in that case, see [OKStatuses.ToFunc]:
+//
+//	_, err := file.Seek(offset, io.SeekStart)
+//	// ...
+//	err = rqx.Get(url,
+//		rqx.WithRange(offset, -1),
+//		rqx.WithPartialContent().ToFunc(func(resp *http.Response) error {
+//			if resp.StatusCode == http.StatusOK {
+//				if _, err := file.Seek(0, io.SeekStart); err != nil {
+//					return err
+//				}
+//				if err := file.Truncate(0); err != nil {
+//					return err
+//				}
+//			}
+//			_, err := io.Copy(file, resp.Body)
+//			return err
+//		}),
+//	)
+func WithPartialContent() OKStatuses {
+	return WithOK(http.StatusOK, http.StatusPartialContent)
+}
 
-			var buffer bytes.Buffer
-			if err := json.NewEncoder(&buffer).Encode(data); err != nil {
-				return err
-			}
-			params.body = bytes.NewReader(buffer.Bytes())
+// WithOKRange returns [OKStatuses] to add a handler for the successful HTTP
+// response whose status code is in the half-open range [from, to).
+func WithOKRange(from, to int) OKStatuses {
+	return OKStatuses(withStatusRange(from, to))
+}
 
-			return nil
-		},
-		WithContentType(string(ContentJSON)),
-	)
+// WithOK2xx returns [OKStatuses] to add a handler for the successful HTTP
+// response with any 2xx status code.
+func WithOK2xx() OKStatuses {
+	return OKStatuses(withStatusClass(StatusClassSuccessful))
 }
 
-// WithXML encodes the given data in XML format as the body content and sets
-// the content type as "application/xml". If the body is already set, it causes
-// the [ErrBodyAlreadyExists] error.
-func WithXML(data any) Option {
-	return optparams.Join[doParams](
-		func(params *doParams) error {
-			if params.body != nil {
-				return ErrBodyAlreadyExists
-			}
+func withStatuses(status int, statuses ...int) responseStatuses {
+	codes := make([]int, 0, 1+len(statuses))
+	codes = append(codes, status)
+	codes = append(codes, statuses...)
 
-			var buffer bytes.Buffer
-			if err := xml.NewEncoder(&buffer).Encode(data); err != nil {
-				return err
-			}
-			params.body = bytes.NewReader(buffer.Bytes())
+	return responseStatuses{codes: codes}
+}
 
-			return nil
-		},
-		WithContentType(string(ContentXML)),
-	)
+func withStatusRange(from, to int) responseStatuses {
+	return responseStatuses{ranges: []statusRange{{from: from, to: to}}}
 }
 
-// WithMultipartForm returns [MultipartFormBuilder] to add multipart sections
-// sequentially before calling the [MultipartFormBuilder.Body] method.
-func WithMultipartForm() *MultipartFormBuilder {
-	var b MultipartFormBuilder
-	b.mw = multipart.NewWriter(&b.buf)
-	return &b
+func withStatusClass(class StatusClass) responseStatuses {
+	return responseStatuses{ranges: []statusRange{class.statusRange()}}
 }
 
-// WithHandlerBeforeResponse adds the given handler to call it right before
-// the sending HTTP request.
-func WithHandlerBeforeResponse(handler BeforeResponseHandler) Option {
+// WithError returns [ErrorStatuses] to add a handler for the error HTTP response.
+func WithError[E error](status int, statuses ...int) ErrorStatuses[E] {
+	return ErrorStatuses[E](withStatuses(status, statuses...))
+}
+
+// WithErrorRange returns [ErrorStatuses] to add a handler for the error HTTP
+// response whose status code is in the half-open range [from, to).
+func WithErrorRange[E error](from, to int) ErrorStatuses[E] {
+	return ErrorStatuses[E](withStatusRange(from, to))
+}
+
+// WithError4xx returns [ErrorStatuses] to add a handler for the error HTTP
+// response with any 4xx status code.
+func WithError4xx[E error]() ErrorStatuses[E] {
+	return ErrorStatuses[E](withStatusClass(StatusClassClientError))
+}
+
+// WithError5xx returns [ErrorStatuses] to add a handler for the error HTTP
+// response with any 5xx status code.
+func WithError5xx[E error]() ErrorStatuses[E] {
+	return ErrorStatuses[E](withStatusClass(StatusClassServerError))
+}
+
+// WithErrorIs adds a handler for the error HTTP response with any of the given
+// status codes that returns [StatusSentinelError] wrapping the given sentinel
+// error, so errors.Is(result, err) reports true. The body is not decoded,
+// only drained.
+func WithErrorIs(err error, status int, statuses ...int) Option {
+	codes := withStatuses(status, statuses...)
+
 	return func(params *doParams) error {
-		params.handler.beforeResponse = append(params.handler.beforeResponse, handler)
+		if err == nil {
+			return errors.New("sentinel error must not be nil")
+		}
+
+		params.handler.hasErrorHandler = true
+		params.handler.errorResponses = append(params.handler.errorResponses,
+			func(resp *http.Response) error {
+				if !codes.contains(resp.StatusCode) {
+					return nil
+				}
+
+				return &StatusSentinelError{Status: resp.StatusCode, Err: err}
+			},
+		)
+
 		return nil
 	}
 }
 
-// WithHandlerAfterResponse adds the given handler to call it immediately after
-// receiving non-nil [net/http.Response].
-func WithHandlerAfterResponse(handler AfterResponseHandler) Option {
+// WithRateLimit returns [RateLimitStatuses] to add a handler for the error HTTP
+// response when the rate limit is reached.
+func WithRateLimit(status int, statuses ...int) RateLimitStatuses {
+	return RateLimitStatuses(withStatuses(status, statuses...))
+}
+
+// WithFailOnErrorStatus converts the HTTP response with the 4xx or 5xx status
+// code that did not match any handlers into the [HTTPStatusError] error
+// instead of the [UnhandledResponseError] one. Other status codes are
+// not affected.
+func WithFailOnErrorStatus() Option {
 	return func(params *doParams) error {
-		params.handler.afterResponse = append(params.handler.afterResponse, handler)
+		params.failOnError = true
 		return nil
 	}
 }
 
-// WithOK returns [OKStatuses] to add a handler for the successful HTTP response.
-// By default, [net/http.StatusOK] is used as the successful HTTP status code.
-func WithOK(statuses ...int) OKStatuses {
-	if len(statuses) == 0 {
-		return []int{http.StatusOK}
+// WithUnhandledDecoder decodes the body of the response that did not match
+// any handlers using [Decoder] to the value returned by the given function,
+// e.g., a pointer to the structured error of the API, and attaches it
+// to [UnhandledResponseError], see [UnhandledResponseError.Decoded].
+// If decoding fails, the raw body is kept as is.
+func WithUnhandledDecoder(decoder Decoder, result func() any) Option {
+	return func(params *doParams) error {
+		if decoder == nil || result == nil {
+			return errors.New("unhandled decoder or result is nil")
+		}
+
+		params.handler.unhandledDecoder = decoder
+		params.handler.unhandledResult = result
+
+		return nil
+	}
+}
+
+// WithRetryOnUnauthorized calls the given [UnauthorizedHandler] on the first
+// HTTP response with the [net/http.StatusUnauthorized] status code and retries
+// the request once, e.g., to refresh the expired access token set by
+// [WithHandlerBeforeResponse]. The subsequent 401 response is handled by
+// the other handlers. Like [RateLimitStatuses.Cooldown], it is not allowed
+// when the request body is [io.Closer].
+func WithRetryOnUnauthorized(handler UnauthorizedHandler) Option {
+	return func(params *doParams) error {
+		if handler == nil {
+			return errors.New("unauthorized handler is nil")
+		}
+
+		params.handler.unauthorizedResponse = handler
+
+		return nil
+	}
+}
+
+// WithConcurrencyLimit bounds the number of requests in flight by the given
+// [Semaphore] shared across requests. Each attempt to send the request waits
+// for a free slot before sending, respecting the context cancellation, and
+// frees it after the response body is closed. Unlike rate limiting, it bounds
+// the number of simultaneous requests, not the number of requests per second.
+func WithConcurrencyLimit(sem *Semaphore) Option {
+	return func(params *doParams) error {
+		params.semaphore = sem
+		return nil
 	}
+}
+
+// WithRetryBudget bounds the retries by the given [RetryBudget] shared across
+// requests. Each request is recorded in the budget, and each retry, e.g.,
+// after [RateLimitStatuses.Cooldown], is consulted with it. If the budget
+// is exhausted, the original error is returned immediately along with
+// [ErrRetryBudgetExhausted].
+func WithRetryBudget(b *RetryBudget) Option {
+	return func(params *doParams) error {
+		params.retryBudget = b
+		return nil
+	}
+}
 
-	return statuses
+// WithDrainOnClose sets the maximum number of bytes of the response body
+// that are read and discarded before closing it, so the keep-alive connection
+// can be reused even if the handlers have not fully read the body. If the rest
+// of the body is larger, the connection is not reused. Zero or a negative
+// value disables draining.
+//
+// The body is drained in all cases: whether the response matched
+// the handlers added by [WithOK], [WithError], or [WithRateLimit], or it is
+// unhandled.
+//
+// By default, up to 256 KiB are drained.
+func WithDrainOnClose(limit int64) Option {
+	return func(params *doParams) error {
+		params.drainLimit = limit
+		return nil
+	}
 }
 
-func withStatuses[S ~[]int](status int, statuses ...int) S {
-	s := make(S, 0, 1+len(statuses))
-	s = append(s, status)
-	s = append(s, statuses...)
+// WithNoDrain disables draining the response body before closing it, e.g.,
+// for huge bodies when dropping the connection is preferable to reading
+// the rest of the body. See [WithDrainOnClose].
+func WithNoDrain() Option {
+	return WithDrainOnClose(0)
+}
 
-	return s
+// WithStrictClose returns the error of closing the response body even if
+// the response is handled successfully. By default, the error is ignored
+// in that case, since the result is already decoded, e.g., if the keep-alive
+// connection fails on close, see [WithCloseErrorHandler]. If the handling
+// failed, the close error is always joined to its error. [ChecksumMismatchError]
+// detected while closing the body is always returned, as are the errors
+// of reading the rest of the body by [WithTee] and [WithVerifyChecksum].
+func WithStrictClose() Option {
+	return func(params *doParams) error {
+		params.strictClose = true
+		return nil
+	}
 }
 
-// WithError returns [ErrorStatuses] to add a handler for the error HTTP response.
-func WithError[E error](status int, statuses ...int) ErrorStatuses[E] {
-	return withStatuses[ErrorStatuses[E]](status, statuses...)
+// WithCloseErrorHandler sets the given function to be called with the error
+// of closing the response body that is ignored since the response is handled
+// successfully, e.g., to log it. See [WithStrictClose].
+func WithCloseErrorHandler(handler func(err error)) Option {
+	return func(params *doParams) error {
+		params.onCloseError = handler
+		return nil
+	}
 }
 
-// WithRateLimit returns [RateLimitStatuses] to add a handler for the error HTTP
-// response when the rate limit is reached.
-func WithRateLimit(status int, statuses ...int) RateLimitStatuses {
-	return withStatuses[RateLimitStatuses](status, statuses...)
+// WithDebug dumps the outgoing requests and the incoming responses,
+// including retries, to the given writer, each marked with the attempt number.
+// The values of the Authorization, Proxy-Authorization, Cookie,
+// and Set-Cookie headers are masked.
+//
+// At most 16 KiB of the bodies are dumped. The request body is dumped only
+// if it can be rewound, i.e., [net/http.Request.GetBody] is set, otherwise
+// a placeholder is printed. The response body is replayed after dumping,
+// so the handlers still can read it.
+func WithDebug(w io.Writer) Option {
+	return func(params *doParams) error {
+		params.debug = &debugger{w: w}
+		return nil
+	}
+}
+
+// WithClientTrace adds the given callbacks called by the client transport
+// while sending each attempt of the request, e.g., to diagnose the latency.
+// The callbacks are composed with the previous ones and with
+// [net/http/httptrace.ClientTrace] already present in the context, if any.
+func WithClientTrace(callbacks ClientTraceCallbacks) Option {
+	return func(params *doParams) error {
+		params.traces = append(params.traces, &callbacks)
+		return nil
+	}
+}
+
+// WithTimingInto stores the durations of the request phases to the given
+// [Timings] after the response body is closed. If the request is retried,
+// the timings of the last attempt are stored.
+func WithTimingInto(timings *Timings) Option {
+	return func(params *doParams) error {
+		if timings == nil {
+			return errors.New("timings is nil")
+		}
+
+		params.timings = timings
+		return nil
+	}
+}
+
+// WithLogger logs each attempt to send the request at the debug level
+// through the given logger: the HTTP method, the URL with the password
+// redacted, the duration, and, if any, the response status code, the response
+// body size (-1 if unknown), and the error. The body content is not logged.
+// If the logger is nil, nothing is logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(params *doParams) error {
+		params.logger = l
+		return nil
+	}
+}
+
+// WithLatencyHistogram observes the duration of each attempt to send
+// the request in seconds by the given histogram after the attempt is completed,
+// including reading the response body by the handlers, or failed. The histogram
+// may be prometheus.Histogram or any other [Observer]. If the histogram is nil,
+// nothing is observed.
+func WithLatencyHistogram(h Observer) Option {
+	return func(params *doParams) error {
+		params.latency = h
+		return nil
+	}
 }
 
 var ErrErrorWrapperAlreadyExists = errors.New("error wrapper already exists")
@@ -262,18 +1713,21 @@ func WithErrorPrefix(prefix string, separator ...string) Option {
 
 // WithErrorWrapper wraps all non-nil errors with the given wrapper.
 func WithErrorWrapper(wrapper ErrorWrapperFunc) Option {
+	return WithErrorWrapperFunc(func(err error, _ ErrorContext) error {
+		return wrapper(err)
+	})
+}
+
+// WithErrorWrapperFunc wraps all non-nil errors with the given wrapper
+// that also receives [ErrorContext] of the request, e.g., to annotate
+// the errors by the response status code.
+func WithErrorWrapperFunc(wrapper ErrorContextWrapperFunc) Option {
 	return func(params *doParams) error {
 		if params.errorWrapper != nil {
 			return ErrErrorWrapperAlreadyExists
 		}
 
-		params.errorWrapper = func(err error) error {
-			if err == nil {
-				return nil
-			}
-
-			return wrapper(err)
-		}
+		params.errorWrapper = wrapper
 
 		return nil
 	}
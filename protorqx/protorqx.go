@@ -0,0 +1,69 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+// Package protorqx encodes and decodes protobuf HTTP bodies.
+//
+// It is a separate module, so that the protobuf dependency is not required
+// by the rqx module unless needed:
+//
+//	go get github.com/tsayukov/rqx/protorqx
+package protorqx
+
+import (
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/tsayukov/rqx"
+)
+
+// ContentProtobuf is the content type of protobuf bodies.
+const ContentProtobuf rqx.ContentType = "application/x-protobuf"
+
+// WithProto encodes the given message in protobuf format as the body content
+// and sets the content type as "application/x-protobuf". If the body is
+// already set, it causes the [rqx.ErrBodyAlreadyExists] error.
+func WithProto(m proto.Message) rqx.Option {
+	return rqx.WithEncodedNamed("protorqx.WithProto", m, Encoder, string(ContentProtobuf))
+}
+
+// ToProto sets a handler for the given [rqx.OKStatuses]. The handler reads
+// and stores protobuf-decoded [net/http.Response.Body] to the given message.
+func ToProto(o rqx.OKStatuses, m proto.Message) rqx.Option {
+	return o.To(m, Decoder)
+}
+
+// Encoder is [rqx.Encoder] for protobuf bodies. The value must implement
+// [proto.Message].
+func Encoder(to io.Writer, from any) error {
+	m, ok := from.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protorqx: %T does not implement proto.Message", from)
+	}
+
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	_, err = to.Write(b)
+
+	return err
+}
+
+// Decoder is [rqx.Decoder] for protobuf bodies. The target must implement
+// [proto.Message].
+func Decoder(from io.Reader, to any) error {
+	m, ok := to.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protorqx: %T does not implement proto.Message", to)
+	}
+
+	b, err := io.ReadAll(from)
+	if err != nil {
+		return err
+	}
+
+	return proto.Unmarshal(b, m)
+}
@@ -0,0 +1,54 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package protorqx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/tsayukov/rqx"
+)
+
+func Test_WithProto(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, string(ContentProtobuf), r.Header.Get(string(rqx.HeaderContentType)))
+
+		w.Header().Set(string(rqx.HeaderContentType), string(ContentProtobuf))
+		_, _ = io.Copy(w, r.Body)
+	}))
+	defer server.Close()
+
+	var out wrapperspb.StringValue
+
+	err := rqx.Post(server.URL,
+		WithProto(wrapperspb.String("echo")),
+		ToProto(rqx.WithOK(), &out),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "echo", out.GetValue())
+
+	var notProto struct{}
+
+	err = rqx.Post(server.URL,
+		WithProto(wrapperspb.String("echo")),
+		rqx.WithOK().To(&notProto, Decoder),
+	)
+	require.Error(t, err)
+
+	err = rqx.Post(server.URL,
+		rqx.WithBytes([]byte("data")),
+		WithProto(wrapperspb.String("echo")),
+	)
+	require.ErrorIs(t, err, rqx.ErrBodyAlreadyExists)
+	assert.EqualError(t, err,
+		"body already exists: set by WithBytes, cannot apply protorqx.WithProto")
+}
@@ -7,7 +7,6 @@ import (
 	"context"
 	"errors"
 	"net/http"
-	"slices"
 )
 
 // RateLimitStatuses are HTTP response status codes that are returned
@@ -19,7 +18,7 @@ var errRateLimit = errors.New("rate limit exceeded")
 // Cooldown adds the given [RateLimitHandler] to the response handlers.
 // Note that when the request body is [io.Closer], [RateLimitHandler]
 // is not allowed, because the body will be closed by [net/http.Client.Do]
-// before the next attempt.
+// before the next attempt. Use [WithBufferBody] to replay such a body.
 func (rc RateLimitStatuses) Cooldown(handler RateLimitHandler) Option {
 	return func(params *doParams) error {
 		if handler == nil {
@@ -30,7 +29,7 @@ func (rc RateLimitStatuses) Cooldown(handler RateLimitHandler) Option {
 
 		params.handler.errorResponses = append(params.handler.errorResponses,
 			func(resp *http.Response) error {
-				if !slices.Contains(rc, resp.StatusCode) {
+				if !responseStatuses(rc).contains(resp.StatusCode) {
 					return nil
 				}
 
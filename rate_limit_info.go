@@ -0,0 +1,106 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitInfo holds the rate limit state reported by the server,
+// see [ParseRateLimitHeaders].
+type RateLimitInfo struct {
+	// Limit is the maximum number of requests in the current window,
+	// or -1 if unknown.
+	Limit int
+
+	// Remaining is the number of requests left in the current window,
+	// or -1 if unknown.
+	Remaining int
+
+	// Reset is the time when the current window resets, or the zero time
+	// if unknown.
+	Reset time.Time
+
+	// RetryAfter is the time set by the Retry-After header after which
+	// the request may be retried, or the zero time if unknown.
+	RetryAfter time.Time
+}
+
+// unixResetThreshold separates the reset values given as the Unix timestamp
+// from the ones given as the delta seconds: no rate limit window is longer
+// than a few decades, and no server clock is earlier than 2004.
+const unixResetThreshold = 1 << 30
+
+// ParseRateLimitHeaders returns [RateLimitInfo] parsed from the given header
+// by the common conventions:
+//   - the RateLimit-Limit, RateLimit-Remaining, and RateLimit-Reset headers
+//     of the IETF draft, e.g., "RateLimit-Limit: 100, 100;w=60";
+//   - the X-RateLimit-Limit, X-RateLimit-Remaining, and X-RateLimit-Reset
+//     headers, which are used if the former ones are missing;
+//   - the Retry-After header given as the delta seconds or the HTTP date.
+//
+// The reset value is either the Unix timestamp or the delta seconds, possibly
+// fractional, and it is normalized to [time.Time] in both cases. The missing
+// or malformed values are reported as unknown.
+func ParseRateLimitHeaders(h http.Header) RateLimitInfo {
+	return parseRateLimitHeaders(h, time.Now())
+}
+
+func parseRateLimitHeaders(h http.Header, now time.Time) RateLimitInfo {
+	info := RateLimitInfo{Limit: -1, Remaining: -1}
+
+	limit := firstHeader(h, HeaderRateLimitLimit, HeaderXRateLimitLimit)
+	if n, ok := parseRateLimitCount(limit); ok {
+		info.Limit = n
+	}
+
+	remaining := firstHeader(h, HeaderRateLimitRemaining, HeaderXRateLimitRemaining)
+	if n, ok := parseRateLimitCount(remaining); ok {
+		info.Remaining = n
+	}
+
+	reset := strings.TrimSpace(firstHeader(h, HeaderRateLimitReset, HeaderXRateLimitReset))
+	if seconds, err := strconv.ParseFloat(reset, 64); err == nil && seconds >= 0 {
+		if seconds >= unixResetThreshold {
+			info.Reset = time.UnixMilli(int64(seconds * 1000))
+		} else {
+			info.Reset = now.Add(time.Duration(seconds * float64(time.Second)))
+		}
+	}
+
+	retryAfter := strings.TrimSpace(h.Get(string(HeaderRetryAfter)))
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		info.RetryAfter = now.Add(time.Duration(seconds) * time.Second)
+	} else if t, err := http.ParseTime(retryAfter); err == nil {
+		info.RetryAfter = t
+	}
+
+	return info
+}
+
+// firstHeader returns the value of the first given header that is present.
+func firstHeader(h http.Header, keys ...HeaderKey) string {
+	for _, key := range keys {
+		if value := h.Get(string(key)); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// parseRateLimitCount parses the leading non-negative number of the given
+// value, skipping the quota policies of the IETF draft, e.g., "100, 100;w=60".
+func parseRateLimitCount(value string) (int, bool) {
+	if i := strings.IndexAny(value, ",;"); i >= 0 {
+		value = value[:i]
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+
+	return n, err == nil && n >= 0
+}
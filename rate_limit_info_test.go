@@ -0,0 +1,141 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseRateLimitHeaders(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   RateLimitInfo
+	}{
+		{
+			name:   "no headers",
+			header: http.Header{},
+			want:   RateLimitInfo{Limit: -1, Remaining: -1},
+		},
+		{
+			name: "X-RateLimit with Unix timestamp",
+			header: http.Header{
+				"X-Ratelimit-Limit":     {"5000"},
+				"X-Ratelimit-Remaining": {"4999"},
+				"X-Ratelimit-Reset":     {"1740834000"},
+			},
+			want: RateLimitInfo{
+				Limit:     5000,
+				Remaining: 4999,
+				Reset:     time.Unix(1740834000, 0),
+			},
+		},
+		{
+			name: "X-RateLimit with fractional delta seconds",
+			header: http.Header{
+				"X-Ratelimit-Remaining": {"0"},
+				"X-Ratelimit-Reset":     {"1.5"},
+			},
+			want: RateLimitInfo{
+				Limit:     -1,
+				Remaining: 0,
+				Reset:     now.Add(1500 * time.Millisecond),
+			},
+		},
+		{
+			name: "RateLimit draft takes precedence",
+			header: http.Header{
+				"Ratelimit-Limit":       {"100, 100;w=60"},
+				"Ratelimit-Remaining":   {"10"},
+				"Ratelimit-Reset":       {"30"},
+				"X-Ratelimit-Limit":     {"200"},
+				"X-Ratelimit-Remaining": {"20"},
+			},
+			want: RateLimitInfo{
+				Limit:     100,
+				Remaining: 10,
+				Reset:     now.Add(30 * time.Second),
+			},
+		},
+		{
+			name: "Retry-After delta seconds",
+			header: http.Header{
+				"Retry-After": {"120"},
+			},
+			want: RateLimitInfo{
+				Limit:      -1,
+				Remaining:  -1,
+				RetryAfter: now.Add(2 * time.Minute),
+			},
+		},
+		{
+			name: "Retry-After HTTP date",
+			header: http.Header{
+				"Retry-After": {"Sat, 01 Mar 2025 12:05:00 GMT"},
+			},
+			want: RateLimitInfo{
+				Limit:      -1,
+				Remaining:  -1,
+				RetryAfter: now.Add(5 * time.Minute),
+			},
+		},
+		{
+			name: "malformed values",
+			header: http.Header{
+				"X-Ratelimit-Limit":     {"many"},
+				"X-Ratelimit-Remaining": {"-1"},
+				"X-Ratelimit-Reset":     {"soon"},
+				"Retry-After":           {"later"},
+			},
+			want: RateLimitInfo{Limit: -1, Remaining: -1},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := parseRateLimitHeaders(tt.header, now)
+			assert.Equal(t, tt.want.Limit, got.Limit)
+			assert.Equal(t, tt.want.Remaining, got.Remaining)
+			assert.True(t, tt.want.Reset.Equal(got.Reset), "reset: %v", got.Reset)
+			assert.True(t, tt.want.RetryAfter.Equal(got.RetryAfter),
+				"retry after: %v", got.RetryAfter)
+		})
+	}
+}
+
+func Test_WithRateLimitInfoInto(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set(string(HeaderXRateLimitLimit), "60")
+		w.Header().Set(string(HeaderXRateLimitRemaining), "59")
+		w.Header().Set(string(HeaderXRateLimitReset), "60")
+	}))
+	defer server.Close()
+
+	var info RateLimitInfo
+	err := Get(server.URL, WithRateLimitInfoInto(&info), WithOK().ToDiscard())
+	require.NoError(t, err)
+
+	assert.Equal(t, 60, info.Limit)
+	assert.Equal(t, 59, info.Remaining)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), info.Reset, 5*time.Second)
+	assert.True(t, info.RetryAfter.IsZero())
+
+	err = Get(server.URL, WithRateLimitInfoInto(nil))
+	require.Error(t, err)
+}
@@ -0,0 +1,91 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"errors"
+	"io"
+)
+
+// replayLimit is the maximum number of bytes of the response body buffered
+// by [replayBody].
+const replayLimit = 1 << 20
+
+// errReplayLimit is returned by reading the view of [replayBody] past
+// the buffered content after another view has read the body past the limit.
+var errReplayLimit = errors.New("response body exceeds the replay limit")
+
+// replayBody reads the body lazily, on demand of its views, and buffers
+// at most limit bytes of it, so each view reads the body from the start,
+// e.g., the error handlers that sniff the body before the one decoding it.
+// A view reading past the limit consumes the body without buffering it.
+// The views must not be read concurrently.
+type replayBody struct {
+	body      io.ReadCloser
+	limit     int
+	buf       []byte
+	truncated bool
+	err       error // the error of reading the body, e.g., io.EOF
+}
+
+func newReplayBody(body io.ReadCloser, limit int) *replayBody {
+	return &replayBody{body: body, limit: limit}
+}
+
+// view returns a new reader of the body from the start. Closing the view
+// closes the body only if owner is true.
+func (b *replayBody) view(owner bool) io.ReadCloser {
+	return &replayView{body: b, owner: owner}
+}
+
+// replayView is the reader of [replayBody] from the start.
+type replayView struct {
+	body  *replayBody
+	pos   int
+	owner bool
+}
+
+func (v *replayView) Read(p []byte) (int, error) {
+	b := v.body
+
+	if v.pos < len(b.buf) {
+		n := copy(p, b.buf[v.pos:])
+		v.pos += n
+
+		return n, nil
+	}
+
+	switch {
+	case v.pos > len(b.buf):
+		// The view has read past the limit: it streams the body.
+		n, err := b.body.Read(p)
+		v.pos += n
+
+		return n, err
+	case b.truncated:
+		return 0, errReplayLimit
+	case b.err != nil:
+		return 0, b.err
+	}
+
+	n, err := b.body.Read(p)
+	if room := b.limit - len(b.buf); n <= room {
+		b.buf = append(b.buf, p[:n]...)
+		b.err = err
+	} else {
+		b.buf = append(b.buf, p[:room]...)
+		b.truncated = true
+	}
+	v.pos += n
+
+	return n, err
+}
+
+func (v *replayView) Close() error {
+	if !v.owner {
+		return nil
+	}
+
+	return v.body.body.Close()
+}
@@ -0,0 +1,71 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_matchError_sharedBody(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = io.WriteString(w, `{"message":"boom"}`)
+	}))
+	defer server.Close()
+
+	var sniffed string
+	sniffer := func(params *doParams) error {
+		params.handler.errorResponses = append(params.handler.errorResponses,
+			func(resp *http.Response) error {
+				content, err := io.ReadAll(resp.Body)
+				sniffed = string(content)
+				return err // nil: the response is left to the next handler
+			})
+		return nil
+	}
+
+	err := Get(server.URL, sniffer, WithError[*apiError](http.StatusInternalServerError).ToJSON())
+
+	var apiErr *apiError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "boom", apiErr.Message)
+	assert.Equal(t, `{"message":"boom"}`, sniffed)
+
+	// The unhandled response still gets the whole body.
+	err = Get(server.URL, sniffer, WithError[*apiError](http.StatusBadRequest).ToJSON())
+	require.ErrorIs(t, err, ErrUnhandledStatus(http.StatusInternalServerError))
+	assert.Contains(t, err.Error(), "boom")
+}
+
+func Test_replayBody(t *testing.T) {
+	t.Parallel()
+
+	body := newReplayBody(io.NopCloser(strings.NewReader("0123456789")), 4)
+
+	first, err := io.ReadAll(body.view(false))
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(first))
+
+	second := make([]byte, 8)
+	n, err := io.ReadFull(body.view(true), second)
+	assert.Equal(t, 4, n)
+	require.ErrorIs(t, err, errReplayLimit)
+	assert.Equal(t, "0123", string(second[:n]))
+
+	body = newReplayBody(io.NopCloser(strings.NewReader("0123")), 4)
+	for i := 0; i < 3; i++ {
+		content, err := io.ReadAll(body.view(false))
+		require.NoError(t, err)
+		assert.Equal(t, "0123", string(content))
+	}
+}
@@ -5,59 +5,205 @@ package rqx
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"time"
 )
 
 // Do sends an HTTP request given [HTTPMethod], URL, and optional parameters.
 //
 // By default, [context.Background] is used. To set an appropriate context,
-// use optional [WithContext].
+// use optional [WithContext]. To cancel the context shared by several requests
+// on the first failure, use optional [WithCancelCause].
+//
+// To pass per-request metadata to the handlers, use optional [WithMeta]
+// or [WithContextValue].
+//
+// To send the request without blocking, use [DoAsync] or [DoExpectAsync].
+// To share the options across similar requests, use [Template].
+// To send a batch of requests concurrently, use [DoAll].
 //
 // By default, [net/http.DefaultClient] is used. To set an appropriate
 // [net/http.Client], use optional [WithClient].
 //
+// Transport errors are wrapped in one of [TimeoutError], [ConnectionError],
+// [DNSError], and [TLSError], if the error chain allows to determine it.
+//
+// The resulting URL must have a non-empty host, the http or https scheme,
+// and no control characters, otherwise [InvalidURLError] is returned.
+//
 // URL options:
 //   - [WithURLPaths];
-//   - [WithQuery].
+//   - [WithQuery];
+//   - [WithQueryIndexed];
+//   - [WithQueryFromMap];
+//   - [WithQueryArray];
+//   - [WithQueryArrayFormat];
+//   - [WithQueryEncoder];
+//   - [WithQuerySkipEmpty];
+//   - [WithAllowDuplicateQueryKeys];
+//   - [WithAllowedSchemes];
+//   - [WithBaseURLCheck];
+//   - [WithMaxURLLength];
+//   - [WithFinalURL].
+//
+// To build the URL without sending the request, use [BuildURL].
 //
 // Headers options:
 //   - [WithHeader];
+//   - [WithMaxHeaderBytes];
 //   - [WithContentType];
-//   - [WithAccept].
+//   - [WithAccept];
+//   - [WithAutoAccept];
+//   - [WithAcceptLanguage];
+//   - [WithAcceptCharset];
+//   - [WithAcceptEncoding];
+//   - [WithRange];
+//   - [WithRequestID];
+//   - [WithGeneratedRequestID];
+//   - [WithMethodOverride].
+//
+// Method options:
+//   - [WithAllowCustomMethod].
 //
 // Authorization options:
 //   - [WithAuth];
-//   - [WithBasicAuth].
+//   - [WithBasicAuth];
+//   - [WithBasicAuthFunc].
 //
 // Body options:
 //   - [WithBody];
+//   - [WithBodyFromFile];
+//   - [WithChunkedBody];
+//   - [WithReplaceBody];
 //   - [WithBytes];
+//   - [WithBodyWriterFunc];
+//   - [WithBufferBody];
 //   - [WithTextPlain];
 //   - [WithJSON];
 //   - [WithXML];
-//   - [WithMultipartForm].
+//   - [WithEncoded];
+//   - [WithMarshaledBody];
+//   - [WithContentMD5];
+//   - [WithDigest];
+//   - [WithMultipartForm];
+//   - [WithExpectContinue].
+//
+// Trailer options:
+//   - [WithTrailer];
+//   - [WithResponseTrailer].
 //
 // Handler options:
 //   - [WithHandlerBeforeResponse];
+//   - [WithRequestMutator];
 //   - [WithHandlerAfterResponse];
+//   - [WithHeadersInto];
+//   - [WithResponseInfo];
+//   - [WithRateLimitInfoInto];
+//   - [WithAllowInto];
+//   - [WithCORSInto];
+//   - [WithOnStatus];
 //   - [WithOK];
+//   - [WithExpectStatus];
+//   - [WithPartialContent];
+//   - [WithValidateResponseBody];
+//   - [WithCSVDelimiter];
+//   - [WithJSONUseNumber];
+//   - [WithJSONInt64];
+//   - [WithDecodeErrorContext];
+//   - [WithExpectContentType];
+//   - [WithExpectHeader];
+//   - [WithVerifyChecksum];
+//   - [WithByteCounter];
+//   - [WithUploadProgress];
+//   - [WithTee];
 //   - [WithError];
-//   - [WithRateLimit].
+//   - [WithErrorIs];
+//   - [WithRateLimit];
+//   - [WithRetryBudget];
+//   - [WithFailOnErrorStatus];
+//   - [WithUnhandledDecoder];
+//   - [WithRetryOnUnauthorized].
+//
+// Connection options:
+//   - [WithConcurrencyLimit];
+//   - [WithAsyncPool];
+//   - [WithDrainOnClose];
+//   - [WithNoDrain];
+//   - [WithStrictClose];
+//   - [WithCloseErrorHandler];
+//   - [WithMaxIdleConnsPerHost];
+//   - [WithMaxConnsPerHost];
+//   - [WithIdleConnTimeout];
+//   - [WithDialTimeout];
+//   - [WithLocalAddr];
+//   - [WithUnixSocket];
+//   - [WithResponseHeaderTimeout];
+//   - [WithBodyReadTimeout];
+//   - [WithDisableKeepAlives];
+//   - [WithCloseConnection];
+//   - [WithSameHostRedirects];
+//   - [WithFaultInjection];
+//   - [WithHedge];
+//   - [WithForceHTTP1];
+//   - [WithHTTP2PriorKnowledge].
+//
+// Debug options:
+//   - [WithDebug];
+//   - [WithClientTrace];
+//   - [WithTimingInto];
+//   - [WithLogger];
+//   - [WithLatencyHistogram].
 //
 // Error Wrapper options:
 //   - [WithErrorPrefix];
-//   - [WithErrorWrapper].
+//   - [WithErrorWrapper];
+//   - [WithErrorWrapperFunc].
 func Do(httpMethod HTTPMethod, url string, opts ...Option) error {
+	started := time.Now()
+
 	params, err := newDoParams(opts...)
 	if err != nil {
 		return err
 	}
+	defer params.closeBodyFile()
 
-	url = params.urlBuilder.build(url)
+	params.started = started
+	params.errorInfo = ErrorContext{HTTPMethod: httpMethod, URL: url}
+
+	if params.async {
+		if err := params.asyncPool.acquire(params.ctx); err != nil {
+			return params.wrapError(err)
+		}
+		defer params.asyncPool.release()
+	}
+
+	if err := params.validateMethod(httpMethod); err != nil {
+		return params.wrapError(err)
+	}
+
+	url, err = params.buildURL(url)
+	if err != nil {
+		return params.wrapError(err)
+	}
+
+	params.errorInfo.URL = url
+	if params.finalURL != nil {
+		*params.finalURL = url
+	}
+
+	httpMethod = params.overrideMethod(httpMethod)
+
+	params.retryBudget.deposit()
 
 	for {
 		tryAgain, err := do(httpMethod, url, params)
 		if err != nil {
+			if params.cancelCause != nil {
+				params.cancelCause(err)
+			}
+
 			return err
 		}
 		if tryAgain {
@@ -98,8 +244,17 @@ func Patch(url string, opts ...Option) error {
 	return Do(PATCH, url, opts...)
 }
 
-func prepareRequest(httpMethod HTTPMethod, url string, params *doParams) (*http.Request, error) {
-	req, err := http.NewRequestWithContext(params.ctx, string(httpMethod), url, params.body)
+func prepareRequest(
+	httpMethod HTTPMethod,
+	url string,
+	body io.Reader,
+	params *doParams,
+	recorder *timingsRecorder,
+) (*http.Request, error) {
+	ctx := withContextValues(withMeta(params.ctx, params.meta), params.values)
+	ctx = withClientTraces(ctx, params.traces, recorder)
+
+	req, err := http.NewRequestWithContext(ctx, string(httpMethod), url, body)
 	if err != nil {
 		return nil, err
 	}
@@ -110,45 +265,213 @@ func prepareRequest(httpMethod HTTPMethod, url string, params *doParams) (*http.
 		req.Header[key] = append(req.Header[key], values...)
 	}
 
+	req.Close = params.closeConn
+	params.setBodyLength(req)
+
+	if len(params.trailers) > 0 {
+		req.Trailer = make(http.Header, len(params.trailers))
+		for _, key := range params.trailers {
+			req.Trailer[key] = nil
+		}
+	}
+
 	return req, nil
 }
 
 func do(httpMethod HTTPMethod, url string, params *doParams) (tryAgain bool, retErr error) {
-	req, err := prepareRequest(httpMethod, url, params)
+	if err := params.startAttempt(); err != nil {
+		return false, params.wrapError(err)
+	}
+
+	body := params.body
+	if params.bodyWriter != nil {
+		pipe := startBodyWriter(params.bodyWriter)
+		defer func() {
+			if err := pipe.stop(); err != nil && !errors.Is(retErr, err) {
+				retErr = errors.Join(retErr, params.wrapError(err))
+			}
+		}()
+		body = pipe
+	}
+
+	recorder := newTimingsRecorder(params.timings)
+
+	req, err := prepareRequest(httpMethod, url, body, params, recorder)
 	if err != nil {
-		return false, params.errorWrapper(err)
+		return false, params.wrapError(err)
 	}
 
-	if err := params.handler.applyBefore(req); err != nil {
-		return false, params.errorWrapper(err)
+	if err := params.beforeSend(req); err != nil {
+		return false, params.wrapError(err)
+	}
+
+	if err := params.semaphore.acquire(req.Context()); err != nil {
+		return false, params.wrapError(err)
 	}
+	defer params.semaphore.release()
+
+	params.debug.dumpRequest(req)
+	params.byteCounter.countRequest(req)
+	params.uploadProgress.track(req, params.errorInfo.Attempt)
+
+	recorder.begin()
+	defer recorder.finish(params.timings)
+
+	start := time.Now()
 
 	resp, err := params.client.Do(req)
 	if err != nil {
-		return false, params.errorWrapper(err)
+		return false, params.transportError(req, err, time.Since(start))
+	}
+
+	params.errorInfo.StatusCode = resp.StatusCode
+
+	defer func() { params.reportRequest(req, resp, time.Since(start), retErr) }()
+
+	params.wrapResponseBody(resp)
+
+	defer func() { retErr = params.closeResponseBody(resp.Body, retErr) }()
+
+	return handleResponse(resp, params)
+}
+
+// beforeSend calls the handlers set by [WithHandlerBeforeResponse]
+// and checks the size of the resulting header, see [WithMaxHeaderBytes].
+func (params *doParams) beforeSend(req *http.Request) error {
+	if err := params.handler.applyBefore(req); err != nil {
+		return err
 	}
 
-	defer func() { retErr = errors.Join(retErr, params.errorWrapper(resp.Body.Close())) }()
+	return params.checkHeaderSize(req.Header)
+}
+
+// transportError classifies and logs the error returned by the client
+// for the given request.
+func (params *doParams) transportError(req *http.Request, err error, elapsed time.Duration) error {
+	err = classifyTransportError(req.Context(), err, params.client, elapsed)
+	err = params.wrapError(err)
+	params.reportRequest(req, nil, elapsed, err)
+
+	return err
+}
+
+// wrapResponseBody wraps the body of the given response to limit the time
+// to read, count, copy, and dump it, if required, before the handlers read it.
+func (params *doParams) wrapResponseBody(resp *http.Response) {
+	limitBodyReadTime(resp, params.bodyReadTimeout)
+	params.byteCounter.countResponse(resp)
+	teeResponse(resp, params.tee)
+	params.debug.dumpResponse(resp)
+}
 
+func handleResponse(resp *http.Response, params *doParams) (tryAgain bool, _ error) {
 	if err := params.handler.applyAfter(resp); err != nil {
-		return false, params.errorWrapper(err)
+		return false, params.wrapError(err)
+	}
+
+	if err := params.handler.applyOnStatus(resp); err != nil {
+		return false, params.wrapError(err)
+	}
+
+	if tryAgain, err := params.handler.retryUnauthorized(params.ctx, resp); tryAgain || err != nil {
+		return tryAgain, params.wrapError(err)
 	}
 
 	if match, err := params.handler.matchOK(resp); match { // if HTTP statuses are OK
-		return false, params.errorWrapper(err) // nil or error
+		if err != nil {
+			return false, params.wrapError(err)
+		}
+
+		return false, params.wrapError(params.handler.applyTrailer(resp)) // nil or error
 	}
 
 	if err := params.handler.matchError(resp); err != nil {
 		if errors.Is(err, errRateLimit) && params.handler.rateLimitResponse != nil {
+			if !params.retryBudget.withdraw() {
+				return false, params.wrapError(
+					fmt.Errorf("%w: %w", ErrRetryBudgetExhausted, err))
+			}
+
 			if err := params.handler.rateLimitResponse(params.ctx, resp); err != nil {
-				return false, params.errorWrapper(err)
+				return false, params.wrapError(err)
 			}
 
 			return true, nil
 		}
 
-		return false, params.errorWrapper(err)
+		return false, params.wrapError(err)
+	}
+
+	if params.failOnError && resp.StatusCode >= http.StatusBadRequest {
+		return false, params.wrapError(newHTTPStatusError(resp))
+	}
+
+	if expected := params.handler.expectedStatus; expected != 0 {
+		return false, params.wrapError(
+			&UnexpectedStatusError{Expected: expected, Actual: resp.StatusCode})
+	}
+
+	return false, params.wrapError(newUnhandledResponse(resp, &params.handler))
+}
+
+// closeResponseBody drains and closes the given response body. The close
+// error is joined to the given error returned by handling the response.
+// If the handling succeeded, the close error is passed to the handler set
+// by [WithCloseErrorHandler], if any, and ignored, unless [WithStrictClose]
+// is set or it is [ChecksumMismatchError] detected while draining the body.
+// Only the error of closing the body returned by the transport is ignored,
+// the errors of the rqx wrappers of the body, see [wrapperCloseError],
+// are always returned.
+func (params *doParams) closeResponseBody(body io.ReadCloser, err error) error {
+	closeErr := drainAndClose(body, params.drainLimit)
+
+	var (
+		mismatch *ChecksumMismatchError
+		wrapper  *wrapperCloseError
+	)
+
+	isWrapperErr := errors.As(closeErr, &wrapper)
+
+	switch {
+	case closeErr == nil || errors.Is(err, closeErr) || isWrapperErr && errors.Is(err, wrapper.err):
+		// The same error may have been returned by reading the body.
+		return err
+	case err != nil:
+		return errors.Join(err, params.wrapError(closeErr))
+	case params.strictClose || errors.As(closeErr, &mismatch) || isWrapperErr:
+		return params.wrapError(closeErr)
+	default:
+		if params.onCloseError != nil {
+			params.onCloseError(closeErr)
+		}
+
+		return nil
+	}
+}
+
+// wrapperCloseError is the error of closing a wrapper of the response body
+// set by rqx that is not the error of closing the body itself, e.g.,
+// the error of copying the rest of the body by [WithTee]. Unlike the latter,
+// it is never ignored, see [doParams.closeResponseBody].
+type wrapperCloseError struct {
+	err error
+}
+
+func (e *wrapperCloseError) Error() string {
+	return e.err.Error()
+}
+
+func (e *wrapperCloseError) Unwrap() error {
+	return e.err
+}
+
+// drainAndClose reads and discards at most limit bytes of the given body
+// before closing it, so the keep-alive connection can be reused if the body
+// has not been fully read.
+func drainAndClose(body io.ReadCloser, limit int64) error {
+	if limit > 0 {
+		_, _ = io.CopyN(io.Discard, body, limit)
 	}
 
-	return false, params.errorWrapper(newUnhandledResponse(resp))
+	return body.Close()
 }
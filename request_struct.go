@@ -0,0 +1,208 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"errors"
+	"fmt"
+	urlpkg "net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/tsayukov/optparams"
+)
+
+const (
+	pathTag  = "path"
+	queryTag = "query"
+	bodyTag  = "body"
+)
+
+var errRequestStruct = errors.New("request must be a non-nil pointer to struct")
+
+// RequestFieldError is an error for the field of the request struct that
+// cannot be used to build the request, see [DoStruct].
+type RequestFieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *RequestFieldError) Error() string {
+	return fmt.Sprintf("request field %s: %v", e.Field, e.Err)
+}
+
+func (e *RequestFieldError) Unwrap() error {
+	return e.Err
+}
+
+var _ error = (*RequestFieldError)(nil)
+
+// DoStruct sends an HTTP request given [HTTPMethod], URL template, the request
+// struct, and optional parameters, see [Do]. The request is built from the
+// fields of the struct pointed to by req, according to their tags:
+//   - `path:"name"` replaces the "{name}" placeholder in the URL template
+//     with the path-escaped string, signed or unsigned integer;
+//   - `query:"name"` adds the query parameter encoded by the go-querystring
+//     conventions, see [WithQuery], e.g., `query:"page,omitempty"`;
+//   - `header:"Name"` sets the HTTP header with the string, signed or unsigned
+//     integer, or []string for a multi-valued header;
+//   - `body:"json"` or `body:"xml"` encodes the field as the body content,
+//     see [WithJSON] and [WithXML].
+//
+// The options built from the struct are applied before the given ones.
+func DoStruct(httpMethod HTTPMethod, urlTemplate string, req any, opts ...Option) error {
+	url, structOpts, err := buildFromStruct(urlTemplate, req)
+	if err != nil {
+		return err
+	}
+
+	return Do(httpMethod, url, append(structOpts, opts...)...)
+}
+
+func buildFromStruct(urlTemplate string, req any) (string, []Option, error) {
+	value := reflect.ValueOf(req)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return "", nil, errRequestStruct
+	}
+
+	structValue := value.Elem()
+	structType := structValue.Type()
+
+	b := structRequest{pathValues: make(map[string]string)}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if err := b.addField(field, structValue.Field(i)); err != nil {
+			return "", nil, &RequestFieldError{Field: field.Name, Err: err}
+		}
+	}
+
+	url, err := fillURLTemplate(urlTemplate, b.pathValues)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(b.queryFields) > 0 {
+		query := reflect.New(reflect.StructOf(b.queryFields)).Elem()
+		for i, v := range b.queryValues {
+			query.Field(i).Set(v)
+		}
+		b.opts = append(b.opts, WithQuery(query.Interface()))
+	}
+
+	return url, b.opts, nil
+}
+
+// structRequest accumulates the parts of the request built from the fields
+// of the request struct, see [DoStruct].
+type structRequest struct {
+	opts        []Option
+	queryFields []reflect.StructField
+	queryValues []reflect.Value
+	pathValues  map[string]string
+	hasBody     bool
+}
+
+// addField adds the parts of the request built from the given field
+// according to its tags.
+func (b *structRequest) addField(field reflect.StructField, fieldValue reflect.Value) error {
+	if name, ok := field.Tag.Lookup(pathTag); ok {
+		s, err := formatRequestField(fieldValue)
+		if err != nil {
+			return err
+		}
+		b.pathValues[name] = urlpkg.PathEscape(s)
+	}
+
+	if name, ok := field.Tag.Lookup(queryTag); ok {
+		b.queryFields = append(b.queryFields, reflect.StructField{
+			Name: field.Name,
+			Type: field.Type,
+			Tag:  reflect.StructTag(fmt.Sprintf(`url:%q`, name)),
+		})
+		b.queryValues = append(b.queryValues, fieldValue)
+	}
+
+	if name, ok := field.Tag.Lookup(headerTag); ok {
+		opt, err := headerFromField(HeaderKey(name), fieldValue)
+		if err != nil {
+			return err
+		}
+		b.opts = append(b.opts, opt)
+	}
+
+	if format, ok := field.Tag.Lookup(bodyTag); ok {
+		if b.hasBody {
+			return ErrBodyAlreadyExists
+		}
+		b.hasBody = true
+
+		switch format {
+		case "json":
+			b.opts = append(b.opts, WithJSON(fieldValue.Interface()))
+		case "xml":
+			b.opts = append(b.opts, WithXML(fieldValue.Interface()))
+		default:
+			return fmt.Errorf("unsupported body format %q", format)
+		}
+	}
+
+	return nil
+}
+
+func fillURLTemplate(urlTemplate string, pathValues map[string]string) (string, error) {
+	url := urlTemplate
+	for name, value := range pathValues {
+		placeholder := "{" + name + "}"
+		if !strings.Contains(url, placeholder) {
+			return "", fmt.Errorf("URL template %q has no placeholder %s", urlTemplate, placeholder)
+		}
+		url = strings.ReplaceAll(url, placeholder, value)
+	}
+
+	if start := strings.IndexByte(url, '{'); start >= 0 {
+		if end := strings.IndexByte(url[start:], '}'); end >= 0 {
+			return "", fmt.Errorf("URL template %q has unfilled placeholder %s",
+				urlTemplate, url[start:start+end+1],
+			)
+		}
+	}
+
+	return url, nil
+}
+
+func formatRequestField(v reflect.Value) (string, error) {
+	switch kind := v.Kind(); {
+	case kind == reflect.String:
+		return v.String(), nil
+	case v.CanInt():
+		return strconv.FormatInt(v.Int(), 10), nil
+	case v.CanUint() && kind != reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+func headerFromField(key HeaderKey, v reflect.Value) (Option, error) {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String {
+		opts := make([]Option, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			opts = append(opts, WithHeader(key, v.Index(i).String(), HeaderAppendModeON))
+		}
+
+		return optparams.Join[doParams](opts...), nil
+	}
+
+	s, err := formatRequestField(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return WithHeader(key, s), nil
+}
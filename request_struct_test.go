@@ -0,0 +1,72 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DoStruct(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"path":   r.URL.EscapedPath(),
+			"query":  r.URL.RawQuery,
+			"tenant": r.Header.Get("X-Tenant"),
+			"tags":   r.Header.Values("X-Tag"),
+			"body":   string(body),
+		})
+	}))
+	defer server.Close()
+
+	type request struct {
+		UserID  int      `path:"userID"`
+		Name    string   `path:"name"`
+		Page    int      `query:"page"`
+		Filter  string   `query:"filter,omitempty"`
+		Tenant  string   `header:"X-Tenant"`
+		Tags    []string `header:"X-Tag"`
+		Payload struct {
+			Value int `json:"value"`
+		} `body:"json"`
+		ignored string
+	}
+
+	req := request{UserID: 42, Name: "a b", Page: 2, Tenant: "acme", Tags: []string{"x", "y"}}
+	req.Payload.Value = 7
+
+	var got map[string]any
+
+	err := DoStruct(POST, server.URL+"/users/{userID}/{name}", &req, WithOK().ToJSON(&got))
+	require.NoError(t, err)
+	assert.Equal(t, "/users/42/a%20b", got["path"])
+	assert.Equal(t, "page=2", got["query"])
+	assert.Equal(t, "acme", got["tenant"])
+	assert.Equal(t, []any{"x", "y"}, got["tags"])
+	assert.JSONEq(t, `{"value":7}`, fmt.Sprint(got["body"]))
+
+	err = DoStruct(GET, server.URL+"/users/{userID}/{missing}", &struct {
+		UserID int `path:"userID"`
+	}{})
+	require.Error(t, err)
+
+	var fieldErr *RequestFieldError
+	err = DoStruct(GET, server.URL+"/{id}", &struct {
+		ID float64 `path:"id"`
+	}{})
+	require.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "ID", fieldErr.Field)
+
+	require.ErrorIs(t, DoStruct(GET, server.URL, request{}), errRequestStruct)
+}
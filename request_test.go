@@ -0,0 +1,1796 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func Test_WithOnStatus(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = io.WriteString(w, `{"id":42}`)
+	}))
+	defer server.Close()
+
+	var (
+		observed []string
+		result   struct {
+			ID int `json:"id"`
+		}
+	)
+
+	err := Get(server.URL,
+		WithOnStatus(http.StatusAccepted, func(resp *http.Response) error {
+			body, err := io.ReadAll(resp.Body)
+			observed = append(observed, string(body))
+			return err
+		}),
+		WithOnStatus(http.StatusCreated, func(*http.Response) error {
+			observed = append(observed, "unexpected")
+			return nil
+		}),
+		WithOK(http.StatusAccepted).ToJSON(&result),
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"id":42}`}, observed)
+	assert.Equal(t, 42, result.ID)
+}
+
+func Test_WithOnStatus_largeBody(t *testing.T) {
+	t.Parallel()
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), (2*replayLimit)/16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	var prefix [16]byte
+	var got bytes.Buffer
+	err := Get(server.URL,
+		WithOnStatus(http.StatusOK, func(*http.Response) error { return nil }),
+		WithOnStatus(http.StatusOK, func(resp *http.Response) error {
+			_, err := io.ReadFull(resp.Body, prefix[:])
+			return err
+		}),
+		WithOK().ToWriter(&got),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef", string(prefix[:]))
+	assert.Equal(t, content, got.Bytes())
+
+	err = Get(server.URL,
+		WithOnStatus(http.StatusOK, func(resp *http.Response) error {
+			_, err := io.Copy(io.Discard, resp.Body)
+			return err
+		}),
+		WithOK().ToWriter(io.Discard),
+	)
+	require.ErrorIs(t, err, errReplayLimit)
+}
+
+func Test_WithRequestMutator(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.URL.RawQuery+" "+strings.Join(r.Header.Values("X-Step"), ","))
+		if len(got) == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	var calls int
+	err := Get(server.URL,
+		WithRequestMutator(func(req *http.Request) {
+			calls++
+			req.URL.RawQuery = fmt.Sprintf("attempt=%d", calls)
+			req.Header.Add("X-Step", "mutator-1")
+		}),
+		WithHandlerBeforeResponse(func(req *http.Request) error {
+			req.Header.Add("X-Step", "handler")
+			return nil
+		}),
+		WithRequestMutator(func(req *http.Request) { req.Header.Add("X-Step", "mutator-2") }),
+		WithRetryOnUnauthorized(func(context.Context, *http.Response) error { return nil }),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.NoError(t, err)
+
+	// The mutators run on each attempt in the order of the options.
+	assert.Equal(t, []string{
+		"attempt=1 mutator-1,handler,mutator-2",
+		"attempt=2 mutator-1,handler,mutator-2",
+	}, got)
+}
+
+func Test_classifyTransportError(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Timeout", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		err := Get(server.URL, WithClient(&http.Client{Timeout: 10 * time.Millisecond}))
+
+		var timeoutErr *TimeoutError
+		require.ErrorAs(t, err, &timeoutErr)
+		assert.Equal(t, TimeoutClient, timeoutErr.Limit)
+		assert.True(t, IsTimeout(err))
+		assert.False(t, IsConnectionRefused(err))
+	})
+
+	t.Run("Context deadline", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := Get(server.URL,
+			WithContext(ctx),
+			WithClient(&http.Client{Timeout: time.Minute}),
+		)
+
+		var timeoutErr *TimeoutError
+		require.ErrorAs(t, err, &timeoutErr)
+		assert.Equal(t, TimeoutContextDeadline, timeoutErr.Limit)
+	})
+
+	t.Run("Connection refused", func(t *testing.T) {
+		t.Parallel()
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := listener.Addr().String()
+		require.NoError(t, listener.Close())
+
+		err = Get("http://" + addr)
+
+		var connErr *ConnectionError
+		require.ErrorAs(t, err, &connErr)
+		assert.True(t, IsConnectionRefused(err))
+		assert.False(t, IsTimeout(err))
+	})
+}
+
+func Test_IsRetryableNetErr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "canceled", err: fmt.Errorf("get: %w", context.Canceled), want: false},
+		{
+			name: "client timeout",
+			err:  &TimeoutError{Limit: TimeoutClient, Err: context.DeadlineExceeded},
+			want: true,
+		},
+		{
+			name: "context deadline",
+			err:  &TimeoutError{Limit: TimeoutContextDeadline, Err: context.DeadlineExceeded},
+			want: false,
+		},
+		{
+			name: "connection reset",
+			err:  &ConnectionError{Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}},
+			want: true,
+		},
+		{name: "broken pipe", err: &net.OpError{Op: "write", Err: syscall.EPIPE}, want: true},
+		{
+			name: "host not found",
+			err:  &DNSError{Err: &net.DNSError{Err: "no such host", IsNotFound: true}},
+			want: false,
+		},
+		{
+			name: "temporary DNS failure",
+			err:  &DNSError{Err: &net.DNSError{Err: "server misbehaving", IsTemporary: true}},
+			want: true,
+		},
+		{
+			name: "TLS",
+			err:  &TLSError{Err: x509.UnknownAuthorityError{}},
+			want: false,
+		},
+		{
+			name: "EOF of idempotent request",
+			err:  &url.Error{Op: "Put", URL: "https://example.com", Err: io.EOF},
+			want: true,
+		},
+		{
+			name: "EOF of non-idempotent request",
+			err:  &url.Error{Op: "Post", URL: "https://example.com", Err: io.ErrUnexpectedEOF},
+			want: false,
+		},
+		{name: "bare EOF", err: io.EOF, want: false},
+		{name: "other", err: errors.New("other"), want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, IsRetryableNetErr(tt.err))
+		})
+	}
+}
+
+type countingReader struct {
+	r     io.Reader
+	reads atomic.Int32
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	c.reads.Add(1)
+	return c.r.Read(p)
+}
+
+func Test_WithExpectContinue(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "100-continue", r.Header.Get(string(HeaderExpect)))
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	body := &countingReader{r: strings.NewReader(strings.Repeat("x", 1<<20))}
+
+	err := Post(server.URL,
+		WithBody(body),
+		WithExpectContinue(time.Minute),
+	)
+
+	var unhandledErr *UnhandledResponseError
+	require.ErrorAs(t, err, &unhandledErr)
+	assert.Equal(t, http.StatusUnauthorized, unhandledErr.StatusCode())
+	assert.Equal(t, int32(0), body.reads.Load())
+}
+
+func Test_WithBasicAuthFunc(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		got = append(got, username+":"+password)
+		if password != "secret-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	type tenantKey struct{}
+
+	var calls int
+	credentials := WithBasicAuthFunc(func(ctx context.Context) (string, string, error) {
+		calls++
+		tenant, _ := ctx.Value(tenantKey{}).(string)
+		return tenant, fmt.Sprintf("secret-%d", calls), nil
+	})
+
+	// The credentials are fetched again for the retried attempt.
+	err := Get(server.URL,
+		WithContextValue(tenantKey{}, "acme"),
+		credentials,
+		WithRetryOnUnauthorized(func(context.Context, *http.Response) error { return nil }),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme:secret-1", "acme:secret-2"}, got)
+
+	got = nil
+	errNoCredentials := errors.New("no credentials")
+	err = Get(server.URL,
+		WithBasicAuthFunc(func(context.Context) (string, string, error) {
+			return "", "", errNoCredentials
+		}),
+	)
+	require.ErrorIs(t, err, errNoCredentials)
+	assert.Empty(t, got)
+}
+
+func Test_WithRequestID(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("X-Request-Id"))
+		if len(got)%2 == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	retry := WithRetryOnUnauthorized(func(context.Context, *http.Response) error { return nil })
+
+	// The same ID is sent on each attempt.
+	err := Get(server.URL, WithRequestID("req-1"), retry, WithExpectStatus(http.StatusOK))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"req-1", "req-1"}, got)
+
+	got = nil
+	var id string
+	err = Get(server.URL, WithGeneratedRequestID(&id), retry, WithExpectStatus(http.StatusOK))
+	require.NoError(t, err)
+	assert.Regexp(t, "^[0-9a-f]{32}$", id)
+	assert.Equal(t, []string{id, id}, got)
+
+	got = nil
+	err = Get(server.URL, WithGeneratedRequestID(nil), retry, WithExpectStatus(http.StatusOK))
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Regexp(t, "^[0-9a-f]{32}$", got[0])
+	assert.NotEqual(t, id, got[0])
+}
+
+func Test_WithDrainOnClose(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		limit      int64
+		wantReused bool
+	}{
+		{
+			name:       "Drained",
+			limit:      2 << 20,
+			wantReused: true,
+		},
+		{
+			name:       "Not drained",
+			limit:      0,
+			wantReused: false,
+		},
+		{
+			name:       "Negative limit",
+			limit:      -1,
+			wantReused: false,
+		},
+		{
+			name:       "Limit below the body size",
+			limit:      1 << 10,
+			wantReused: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					_, _ = io.WriteString(w, strings.Repeat("x", 1<<20))
+				}),
+			)
+			defer server.Close()
+
+			client := &http.Client{Transport: &http.Transport{}}
+
+			var reused []bool
+			ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+				GotConn: func(info httptrace.GotConnInfo) {
+					reused = append(reused, info.Reused)
+				},
+			})
+
+			for i := 0; i < 2; i++ {
+				err := Get(server.URL,
+					WithContext(ctx),
+					WithClient(client),
+					WithDrainOnClose(tt.limit),
+					WithOK().To(new(string), func(io.Reader, any) error { return nil }),
+				)
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, []bool{false, tt.wantReused}, reused)
+		})
+	}
+}
+
+func Test_WithRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		start, end int64
+		want       string
+		wantErr    bool
+	}{
+		{name: "closed range", start: 10, end: 19, want: "bytes=10-19"},
+		{name: "single byte", start: 0, end: 0, want: "bytes=0-0"},
+		{name: "open-ended range", start: 100, end: -1, want: "bytes=100-"},
+		{name: "negative start", start: -1, end: 10, wantErr: true},
+		{name: "end before start", start: 10, end: 9, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got string
+			server := httptest.NewServer(
+				http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+					got = r.Header.Get("Range")
+				}),
+			)
+			defer server.Close()
+
+			err := Get(server.URL, WithRange(tt.start, tt.end), WithOK().ToDiscard())
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_WithPartialContent(t *testing.T) {
+	t.Parallel()
+
+	const content = "0123456789"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/no-ranges" {
+			_, _ = io.WriteString(w, content)
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	resume := func(t *testing.T, url string) string {
+		t.Helper()
+
+		file, err := os.Create(filepath.Join(t.TempDir(), "download"))
+		require.NoError(t, err)
+		defer file.Close()
+
+		_, err = io.WriteString(file, content[:4])
+		require.NoError(t, err)
+
+		err = Get(url,
+			WithRange(4, -1),
+			WithPartialContent().ToFunc(func(resp *http.Response) error {
+				if resp.StatusCode == http.StatusOK {
+					if _, err := file.Seek(0, io.SeekStart); err != nil {
+						return err
+					}
+					if err := file.Truncate(0); err != nil {
+						return err
+					}
+				}
+				_, err := io.Copy(file, resp.Body)
+				return err
+			}),
+		)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(file.Name())
+		require.NoError(t, err)
+
+		return string(data)
+	}
+
+	assert.Equal(t, content, resume(t, server.URL))
+	assert.Equal(t, content, resume(t, server.URL+"/no-ranges"))
+
+	var body strings.Builder
+	err := Get(server.URL, WithRange(2, 4), WithPartialContent().ToWriter(&body))
+	require.NoError(t, err)
+	assert.Equal(t, "234", body.String())
+}
+
+func Test_ErrUnhandledStatus(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := Get(server.URL, WithErrorPrefix("get"))
+
+	require.ErrorIs(t, err, ErrUnhandledStatus(http.StatusNotFound))
+	require.NotErrorIs(t, err, ErrUnhandledStatus(http.StatusBadRequest))
+
+	status, ok := StatusCodeFromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	_, ok = StatusCodeFromError(io.EOF)
+	assert.False(t, ok)
+}
+
+func Test_WithFailOnErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("x", 3<<10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.Header().Set("X-Trace", "trace-1")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, long)
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/accepted":
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+
+	err := Get(server.URL+"/missing", WithFailOnErrorStatus())
+	var statusErr *HTTPStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.Status)
+	assert.Equal(t, long[:2<<10], statusErr.Snippet)
+	assert.Equal(t, "trace-1", statusErr.Header.Get("X-Trace"))
+	require.ErrorIs(t, err, ErrUnhandledStatus(http.StatusNotFound))
+
+	status, ok := StatusCodeFromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	err = Get(server.URL+"/error", WithFailOnErrorStatus())
+	require.ErrorAs(t, err, &statusErr)
+	require.EqualError(t, err, "HTTP status 500 Internal Server Error")
+
+	// The handlers take precedence.
+	errNotFound := errors.New("not found")
+	err = Get(server.URL+"/missing",
+		WithFailOnErrorStatus(),
+		WithErrorIs(errNotFound, http.StatusNotFound),
+	)
+	require.ErrorIs(t, err, errNotFound)
+	require.NotErrorAs(t, err, &statusErr)
+
+	// Other status codes are not affected.
+	err = Get(server.URL+"/accepted", WithFailOnErrorStatus(), WithOK().ToDiscard())
+	require.NotErrorAs(t, err, &statusErr)
+	var unhandled *UnhandledResponseError
+	require.ErrorAs(t, err, &unhandled)
+}
+
+func Test_WithErrorWrapperFunc(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	var info ErrorContext
+	wrapper := WithErrorWrapperFunc(func(err error, i ErrorContext) error {
+		info = i
+		return fmt.Errorf("wrapped: %w", err)
+	})
+
+	err := Get(server.URL, WithURLPaths("items"), wrapper)
+	require.ErrorIs(t, err, ErrUnhandledStatus(http.StatusNotFound))
+	require.ErrorContains(t, err, "wrapped: ")
+	assert.Equal(t, GET, info.HTTPMethod)
+	assert.Equal(t, server.URL+"/items", info.URL)
+	assert.Equal(t, http.StatusNotFound, info.StatusCode)
+	assert.Equal(t, 1, info.Attempt)
+	assert.Positive(t, info.Elapsed)
+
+	info = ErrorContext{}
+	err = Get("ftp://example.com", wrapper)
+	require.ErrorContains(t, err, "wrapped: ")
+	assert.Equal(t, "ftp://example.com", info.URL)
+	assert.Zero(t, info.StatusCode)
+	assert.Zero(t, info.Attempt)
+
+	err = Get(server.URL, WithErrorPrefix("get"), wrapper)
+	require.ErrorIs(t, err, ErrErrorWrapperAlreadyExists)
+}
+
+func Test_WithErrorIs(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/conflict":
+			w.WriteHeader(http.StatusConflict)
+		}
+		_, _ = io.WriteString(w, `{"message": "ignored"}`)
+	}))
+	defer server.Close()
+
+	errNotFound := errors.New("not found")
+	errConflict := errors.New("conflict")
+	opts := []Option{
+		WithErrorIs(errNotFound, http.StatusNotFound, http.StatusGone),
+		WithErrorIs(errConflict, http.StatusConflict),
+	}
+
+	err := Get(server.URL+"/missing", opts...)
+	require.ErrorIs(t, err, errNotFound)
+	require.NotErrorIs(t, err, errConflict)
+	require.EqualError(t, err, "HTTP status 404 Not Found: not found")
+	status, ok := StatusCodeFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	err = Get(server.URL+"/conflict", opts...)
+	require.ErrorIs(t, err, errConflict)
+
+	err = Get(server.URL, append(opts, WithOK().ToDiscard())...)
+	require.NoError(t, err)
+
+	err = Get(server.URL, WithErrorIs(nil, http.StatusNotFound))
+	require.Error(t, err)
+}
+
+func Test_WithAutoAccept(t *testing.T) {
+	t.Parallel()
+
+	var result any
+	var rows []csvReport
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "disabled",
+			opts: []Option{WithOK().ToJSON(&result)},
+		},
+		{
+			name: "JSON",
+			opts: []Option{WithOK().ToJSON(&result), WithAutoAccept()},
+			want: "application/json",
+		},
+		{
+			name: "XML before option",
+			opts: []Option{WithAutoAccept(), WithOK().ToXMLThen(&result, nil)},
+			want: "application/xml",
+		},
+		{
+			name: "CSV",
+			opts: []Option{WithAutoAccept(), WithOK().ToCSV(&rows)},
+			want: "text/csv",
+		},
+		{
+			name: "explicit header",
+			opts: []Option{WithAutoAccept(), WithOK().ToJSON(&result), WithAccept("text/plain")},
+			want: "text/plain",
+		},
+		{
+			name: "overwritten by custom decoder",
+			opts: []Option{
+				WithAutoAccept(),
+				WithOK().ToJSON(&result),
+				WithOK().To(&result, func(io.Reader, any) error { return nil }),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			params, err := newDoParams(tt.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, params.headers.Get(string(HeaderAccept)))
+		})
+	}
+}
+
+type failingCloseBody struct {
+	io.Reader
+}
+
+func (failingCloseBody) Close() error {
+	return errors.New("close failed")
+}
+
+func Test_WithStrictClose(t *testing.T) {
+	t.Parallel()
+
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		status := http.StatusOK
+		if r.URL.Path == "/error" {
+			status = http.StatusNotFound
+		}
+
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{},
+			Body:       failingCloseBody{strings.NewReader("ok")},
+			Request:    r,
+		}, nil
+	})
+	client := &http.Client{Transport: transport}
+
+	var closeErr error
+	err := Get("https://example.com",
+		WithClient(client),
+		WithOK().ToDiscard(),
+		WithCloseErrorHandler(func(err error) { closeErr = err }),
+	)
+	require.NoError(t, err)
+	require.EqualError(t, closeErr, "close failed")
+
+	err = Get("https://example.com", WithClient(client), WithOK().ToDiscard(), WithStrictClose())
+	require.EqualError(t, err, "close failed")
+
+	err = Get("https://example.com/error", WithClient(client), WithOK().ToDiscard())
+	require.ErrorIs(t, err, ErrUnhandledStatus(http.StatusNotFound))
+	require.ErrorContains(t, err, "close failed")
+}
+
+func Benchmark_drainAndClose(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, strings.Repeat("x", 1<<20))
+	}))
+	defer server.Close()
+
+	benchmarks := []struct {
+		name string
+		opt  Option
+	}{
+		{name: "Drain", opt: WithDrainOnClose(2 << 20)},
+		{name: "NoDrain", opt: WithNoDrain()},
+	}
+
+	for _, bb := range benchmarks {
+		b.Run(bb.name, func(b *testing.B) {
+			client := &http.Client{Transport: &http.Transport{}}
+
+			var newConns int
+			ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+				GotConn: func(info httptrace.GotConnInfo) {
+					if !info.Reused {
+						newConns++
+					}
+				},
+			})
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				err := Get(server.URL,
+					WithContext(ctx),
+					WithClient(client),
+					bb.opt,
+					WithOK().To(new(string), func(io.Reader, any) error { return nil }),
+				)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(newConns)/float64(b.N), "conns/op")
+		})
+	}
+}
+
+func Test_WithUnhandledDecoder(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		if r.URL.Query().Has("json") {
+			_, _ = io.WriteString(w, `{"message":"short and stout"}`)
+		} else {
+			_, _ = io.WriteString(w, "plain text")
+		}
+	}))
+	defer server.Close()
+
+	decoder := func(from io.Reader, to any) error {
+		return json.NewDecoder(from).Decode(to)
+	}
+	newAPIError := func() any { return &apiError{} }
+
+	err := Get(server.URL+"?json", WithUnhandledDecoder(decoder, newAPIError))
+	var unhandled *UnhandledResponseError
+	require.ErrorAs(t, err, &unhandled)
+	assert.Equal(t, &apiError{Message: "short and stout"}, unhandled.Decoded())
+	assert.Contains(t, err.Error(), "body: short and stout")
+
+	err = Get(server.URL, WithUnhandledDecoder(decoder, newAPIError))
+	require.ErrorAs(t, err, &unhandled)
+	assert.Nil(t, unhandled.Decoded())
+	assert.Contains(t, err.Error(), "body: plain text")
+}
+
+func Test_WithMeta(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	type metaKey string
+
+	var got []string
+
+	err := Get(server.URL,
+		WithMeta(metaKey("service"), "users"),
+		WithMeta(metaKey("attempts"), 3),
+		WithHandlerBeforeResponse(func(req *http.Request) error {
+			service, ok := Meta[string](req, metaKey("service"))
+			assert.True(t, ok)
+			got = append(got, service)
+
+			_, ok = Meta[string](req, metaKey("attempts"))
+			assert.False(t, ok)
+
+			return nil
+		}),
+		WithHandlerAfterResponse(func(resp *http.Response) error {
+			attempts, ok := Meta[int](resp.Request, metaKey("attempts"))
+			assert.True(t, ok)
+			assert.Equal(t, 3, attempts)
+
+			_, ok = MetaFromRequest(resp.Request, metaKey("missing"))
+			assert.False(t, ok)
+
+			return nil
+		}),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users"}, got)
+}
+
+func Test_WithCancelCause(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	err := Get(server.URL, WithContext(ctx), WithCancelCause(cancel), WithOK().ToDiscard())
+	require.NoError(t, err)
+	require.NoError(t, ctx.Err())
+
+	err = Get(server.URL+"/fail", WithContext(ctx), WithCancelCause(cancel), WithOK().ToDiscard())
+	require.ErrorIs(t, err, ErrUnhandledStatus(http.StatusInternalServerError))
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+	assert.Equal(t, err, context.Cause(ctx))
+
+	err = Get(server.URL, WithContext(ctx), WithOK().ToDiscard())
+	require.Error(t, err)
+
+	err = Get(server.URL, WithCancelCause(nil))
+	require.Error(t, err)
+}
+
+func Test_WithContextValue(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	type ctxKey string
+
+	ctx := context.WithValue(context.Background(), ctxKey("tenant"), "acme")
+
+	err := Get(server.URL,
+		WithContextValue(ctxKey("trace"), "first"),
+		WithContextValue(ctxKey("trace"), "second"),
+		WithContextValue(ctxKey("user"), 42),
+		WithContext(ctx),
+		WithHandlerAfterResponse(func(resp *http.Response) error {
+			reqCtx := resp.Request.Context()
+			assert.Equal(t, "second", reqCtx.Value(ctxKey("trace")))
+			assert.Equal(t, 42, reqCtx.Value(ctxKey("user")))
+			assert.Equal(t, "acme", reqCtx.Value(ctxKey("tenant")))
+			return nil
+		}),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.NoError(t, err)
+}
+
+func Test_WithConcurrencyLimit(t *testing.T) {
+	t.Parallel()
+
+	var inFlight, maxInFlight atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	sem := NewSemaphore(2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, Get(server.URL,
+				WithConcurrencyLimit(sem),
+				WithExpectStatus(http.StatusOK),
+			))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), maxInFlight.Load())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	full := NewSemaphore(1)
+	require.NoError(t, full.acquire(context.Background()))
+	require.ErrorIs(t, full.acquire(ctx), context.Canceled)
+}
+
+func Test_WithHTTP2PriorKnowledge(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proto", r.Proto)
+	})
+	server := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	defer server.Close()
+
+	var info ResponseInfo
+
+	err := Get(server.URL,
+		WithHTTP2PriorKnowledge(),
+		WithResponseInfo(&info),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, info.StatusCode)
+	assert.Equal(t, "HTTP/2.0", info.Proto)
+	assert.Equal(t, "HTTP/2.0", info.Header.Get("X-Proto"))
+
+	err = Get(server.URL, WithResponseInfo(&info), WithExpectStatus(http.StatusOK))
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1", info.Proto)
+
+	err = Get(server.URL, WithHTTP2PriorKnowledge(), WithForceHTTP1())
+	require.ErrorIs(t, err, errConflictingProtocols)
+
+	err = Get(server.URL, WithResponseInfo(nil))
+	require.Error(t, err)
+}
+
+func Test_WithForceHTTP1(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	server := httptest.NewUnstartedServer(handler)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	var info ResponseInfo
+
+	err := Get(server.URL,
+		WithClient(server.Client()),
+		WithResponseInfo(&info),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0", info.Proto)
+
+	err = Get(server.URL,
+		WithClient(server.Client()),
+		WithForceHTTP1(),
+		WithResponseInfo(&info),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1", info.Proto)
+}
+
+func Test_WithCloseConnection(t *testing.T) {
+	t.Parallel()
+
+	var closeRequested []bool
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		closeRequested = append(closeRequested, r.Close)
+	}))
+	defer server.Close()
+
+	reusedConns := func(opts ...Option) []bool {
+		var reused []bool
+		ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) {
+				reused = append(reused, info.Reused)
+			},
+		})
+
+		client := &http.Client{Transport: &http.Transport{}}
+		opts = append(opts, WithContext(ctx), WithClient(client), WithOK().ToDiscard())
+		for i := 0; i < 2; i++ {
+			require.NoError(t, Get(server.URL, opts...))
+		}
+
+		return reused
+	}
+
+	assert.Equal(t, []bool{false, true}, reusedConns())
+	assert.Equal(t, []bool{false, false}, closeRequested)
+
+	closeRequested = nil
+	assert.Equal(t, []bool{false, false}, reusedConns(WithCloseConnection()))
+	assert.Equal(t, []bool{true, true}, closeRequested)
+}
+
+func Test_WithSameHostRedirects(t *testing.T) {
+	t.Parallel()
+
+	other := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer other.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/same", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/other", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL, http.StatusFound)
+	})
+	mux.HandleFunc("/ok", func(http.ResponseWriter, *http.Request) {})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	err := Get(server.URL+"/same",
+		WithSameHostRedirects(),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.NoError(t, err)
+
+	err = Get(server.URL+"/other",
+		WithSameHostRedirects(),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.ErrorIs(t, err, ErrCrossHostRedirect)
+
+	err = Get(server.URL+"/other",
+		WithExpectStatus(http.StatusOK),
+	)
+	assert.NoError(t, err)
+}
+
+func Test_WithBodyWriterFunc(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(string(HeaderContentType), r.Header.Get(string(HeaderContentType)))
+		_, _ = io.Copy(w, r.Body)
+	}))
+	defer server.Close()
+
+	writeRecords := func(w io.Writer) error {
+		for i := 0; i < 3; i++ {
+			if _, err := fmt.Fprintf(w, "{\"id\":%d}\n", i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var got strings.Builder
+	var info ResponseInfo
+
+	err := Post(server.URL,
+		WithBodyWriterFunc(writeRecords, "application/x-ndjson"),
+		WithResponseInfo(&info),
+		WithOK().ToWriter(&got),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"id\":0}\n{\"id\":1}\n{\"id\":2}\n", got.String())
+	assert.Equal(t, "application/x-ndjson", info.Header.Get(string(HeaderContentType)))
+
+	errWrite := errors.New("write failed")
+
+	err = Post(server.URL,
+		WithBodyWriterFunc(func(w io.Writer) error {
+			_, _ = w.Write([]byte("partial"))
+			return errWrite
+		}, string(ContentTextPlain)),
+		WithOK().ToDiscard(),
+	)
+	require.ErrorIs(t, err, errWrite)
+
+	err = Post(server.URL,
+		WithBytes([]byte("data")),
+		WithBodyWriterFunc(writeRecords, string(ContentTextPlain)),
+	)
+	require.ErrorIs(t, err, ErrBodyAlreadyExists)
+}
+
+func Test_WithBodyWriterFunc_failedRequest(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	url := "http://" + listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	returned := make(chan error, 1)
+
+	err = Post(url,
+		WithBodyWriterFunc(func(w io.Writer) error {
+			for {
+				if _, err := w.Write([]byte("data")); err != nil {
+					returned <- err
+					return err
+				}
+			}
+		}, string(ContentTextPlain)),
+		WithOK().ToDiscard(),
+	)
+	require.True(t, IsConnectionRefused(err))
+	require.NotErrorIs(t, err, errBodyWriterStopped)
+
+	select {
+	case err := <-returned:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("body writer goroutine leaked")
+	}
+
+	err = Post(url,
+		WithBodyWriterFunc(func(w io.Writer) error {
+			_, err := w.Write([]byte("data"))
+			returned <- err
+			return err
+		}, string(ContentTextPlain)),
+		WithHandlerBeforeResponse(func(*http.Request) error { return errors.New("before") }),
+	)
+	require.EqualError(t, err, "before")
+	assert.ErrorIs(t, <-returned, errBodyWriterStopped)
+}
+
+type selfRendered string
+
+func (s selfRendered) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, "writer:"+string(s))
+	return int64(n), err
+}
+
+func (s selfRendered) MarshalJSON() ([]byte, error) {
+	return []byte(`"json"`), nil
+}
+
+func Test_WithMarshaledBody(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(string(HeaderContentType), r.Header.Get(string(HeaderContentType)))
+		_, _ = io.Copy(w, r.Body)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{name: "io.WriterTo", value: selfRendered("a"), want: "writer:a"},
+		{name: "json.Marshaler", value: json.RawMessage(`{"a":1}`), want: `{"a":1}`},
+		{name: "encoding.TextMarshaler", value: net.IPv4(127, 0, 0, 1), want: "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var got strings.Builder
+			var info ResponseInfo
+
+			err := Post(server.URL,
+				WithMarshaledBody(tt.value, "application/x-test"),
+				WithResponseInfo(&info),
+				WithOK().ToWriter(&got),
+			)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+			assert.Equal(t, "application/x-test", info.Header.Get(string(HeaderContentType)))
+		})
+	}
+
+	err := Post(server.URL, WithMarshaledBody(42, "text/plain"))
+	require.ErrorIs(t, err, ErrNotMarshaler)
+
+	err = Post(server.URL, WithBytes(nil), WithMarshaledBody(selfRendered("a"), "text/plain"))
+	require.ErrorIs(t, err, ErrBodyAlreadyExists)
+}
+
+func Test_contentNegotiationHeaders(t *testing.T) {
+	t.Parallel()
+
+	var got http.Header
+
+	err := Get("https://example.com",
+		WithAcceptLanguage("en-US, en;q=0.9"),
+		WithAcceptLanguage("fr;q=0.5", HeaderAppendModeON),
+		WithAcceptCharset("utf-8"),
+		WithAcceptEncoding("identity"),
+		WithClient(&http.Client{Transport: roundTripperFunc(
+			func(req *http.Request) (*http.Response, error) {
+				got = req.Header
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			},
+		)}),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"en-US, en;q=0.9", "fr;q=0.5"}, got.Values("Accept-Language"))
+	assert.Equal(t, "utf-8", got.Get("Accept-Charset"))
+	assert.Equal(t, "identity", got.Get("Accept-Encoding"))
+}
+
+func Test_WithBufferBody(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		_, _ = io.Copy(w, r.Body)
+	}))
+	defer server.Close()
+
+	cooldown := func(context.Context, *http.Response) error { return nil }
+
+	var got strings.Builder
+
+	err := Post(server.URL,
+		WithBody(io.NopCloser(strings.NewReader("payload"))),
+		WithBufferBody(),
+		WithRateLimit(http.StatusTooManyRequests).Cooldown(cooldown),
+		WithOK().ToWriter(&got),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), attempts.Load())
+	assert.Equal(t, "payload", got.String())
+
+	err = Post(server.URL,
+		WithBody(io.NopCloser(strings.NewReader("payload"))),
+		WithRateLimit(http.StatusTooManyRequests).Cooldown(cooldown),
+	)
+	require.Error(t, err)
+}
+
+func Test_WithRetryBudget(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	cooldown := func(context.Context, *http.Response) error { return nil }
+	budget := NewRetryBudget(0.5, time.Hour, 2)
+
+	err := Get(server.URL,
+		WithRateLimit(http.StatusTooManyRequests).Cooldown(cooldown),
+		WithRetryBudget(budget),
+	)
+	require.ErrorIs(t, err, ErrRetryBudgetExhausted)
+	require.ErrorIs(t, err, errRateLimit)
+	assert.Equal(t, int32(3), attempts.Load())
+
+	err = Get(server.URL,
+		WithRateLimit(http.StatusTooManyRequests).Cooldown(cooldown),
+		WithRetryBudget(budget),
+	)
+	require.ErrorIs(t, err, ErrRetryBudgetExhausted)
+	assert.Equal(t, int32(4), attempts.Load())
+
+	assert.Equal(t, RetryBudgetStats{Requests: 2, Retries: 2, Exhausted: 2}, budget.Stats())
+
+	for i := 0; i < 4; i++ {
+		budget.deposit()
+	}
+	assert.True(t, budget.withdraw())
+	assert.False(t, budget.withdraw())
+}
+
+func Test_WithRetryOnUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		if r.Header.Get(string(HeaderAuthorization)) != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	token := "stale"
+	refresh := func(context.Context, *http.Response) error {
+		token = "fresh"
+		return nil
+	}
+	auth := WithHandlerBeforeResponse(func(req *http.Request) error {
+		req.Header.Set(string(HeaderAuthorization), "Bearer "+token)
+		return nil
+	})
+
+	err := Get(server.URL, auth, WithRetryOnUnauthorized(refresh), WithExpectStatus(http.StatusOK))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), attempts.Load())
+
+	attempts.Store(0)
+	token = "revoked"
+	refresh = func(context.Context, *http.Response) error { return nil }
+
+	err = Get(server.URL, auth, WithRetryOnUnauthorized(refresh), WithExpectStatus(http.StatusOK))
+	var statusErr *UnexpectedStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusUnauthorized, statusErr.Actual)
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func Test_WithExpectContentType(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set(string(HeaderContentType), "Application/JSON; charset=utf-8")
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		case "/html":
+			w.Header().Set(string(HeaderContentType), "text/html; charset=utf-8")
+			_, _ = w.Write([]byte("<html>maintenance</html>"))
+		default:
+			w.Header().Set(string(HeaderContentType), "text/html")
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	var result struct{ OK bool }
+
+	err := Get(server.URL+"/json",
+		WithExpectContentType(ContentJSON),
+		WithOK().ToJSON(&result),
+	)
+	require.NoError(t, err)
+	assert.True(t, result.OK)
+
+	err = Get(server.URL+"/html",
+		WithExpectContentType(ContentJSON),
+		WithOK().ToJSON(&result),
+	)
+	var contentTypeErr *UnexpectedContentTypeError
+	require.ErrorAs(t, err, &contentTypeErr)
+	assert.Equal(t, "text/html; charset=utf-8", contentTypeErr.Actual)
+
+	err = Get(server.URL+"/json",
+		WithExpectContentType(ContentXML, "application/*"),
+		WithOK().ToJSON(&result),
+	)
+	require.NoError(t, err)
+
+	err = Get(server.URL+"/html",
+		WithExpectContentType(ContentJSON, "application/*"),
+		WithOK().ToJSON(&result),
+	)
+	require.ErrorAs(t, err, &contentTypeErr)
+	assert.EqualError(t, err, `unexpected response content type "text/html; charset=utf-8", `+
+		`expected application/json or application/*`)
+
+	err = Get(server.URL+"/html", WithExpectContentType("*/*"), WithOK().ToDiscard())
+	require.NoError(t, err)
+
+	// Non-2xx responses are left to the error handlers.
+	err = Get(server.URL+"/error",
+		WithExpectContentType(ContentJSON),
+		WithOK().ToJSON(&result),
+	)
+	require.ErrorIs(t, err, ErrUnhandledStatus(http.StatusInternalServerError))
+}
+
+func Test_WithExpectHeader(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/cached" {
+			w.Header().Set("X-Cache", "HIT")
+		}
+	}))
+	defer server.Close()
+
+	isHit := func(value string) bool { return value == "HIT" }
+
+	err := Get(server.URL+"/cached", WithExpectHeader("X-Cache", isHit), WithOK().ToDiscard())
+	require.NoError(t, err)
+
+	err = Get(server.URL+"/origin", WithExpectHeader("X-Cache", isHit), WithOK().ToDiscard())
+	var headerErr *UnexpectedHeaderError
+	require.ErrorAs(t, err, &headerErr)
+	assert.Equal(t, HeaderKey("X-Cache"), headerErr.Key)
+	assert.EqualError(t, err, "unexpected response without header X-Cache")
+}
+
+func Test_ErrBodyAlreadyExists(t *testing.T) {
+	t.Parallel()
+
+	_, err := newDoParams(WithJSON(map[string]int{"a": 1}), WithBytes([]byte("data")))
+	require.ErrorIs(t, err, ErrBodyAlreadyExists)
+	require.EqualError(t, err, "body already exists: set by WithJSON, cannot apply WithBytes")
+
+	form := WithMultipartForm().AddString("field", "value")
+	_, err = newDoParams(WithTextPlain("text"), form.Body())
+	require.ErrorIs(t, err, ErrBodyAlreadyExists)
+	require.EqualError(t, err,
+		"body already exists: set by WithTextPlain, cannot apply MultipartFormBuilder.Body")
+
+	encoder := func(to io.Writer, from any) error {
+		_, err := fmt.Fprint(to, from)
+		return err
+	}
+	_, err = newDoParams(
+		WithEncodedNamed("yamlrqx.WithYAML", "a: 1", encoder, "application/yaml"),
+		WithEncoded("data", encoder, "text/plain"),
+	)
+	require.ErrorIs(t, err, ErrBodyAlreadyExists)
+	assert.EqualError(t, err,
+		"body already exists: set by yamlrqx.WithYAML, cannot apply WithEncoded")
+}
+
+func Test_WithReplaceBody(t *testing.T) {
+	t.Parallel()
+
+	params, err := newDoParams(
+		WithJSON(map[string]int{"a": 1}),
+		WithReplaceBody(strings.NewReader("replaced")),
+	)
+	require.NoError(t, err)
+	assert.Empty(t, params.headers.Get(string(HeaderContentType)))
+
+	content, err := io.ReadAll(params.body)
+	require.NoError(t, err)
+	assert.Equal(t, "replaced", string(content))
+
+	params, err = newDoParams(
+		WithBodyWriterFunc(func(io.Writer) error { return nil }, "text/plain"),
+		WithReplaceBody(strings.NewReader("replaced")),
+	)
+	require.NoError(t, err)
+	assert.Nil(t, params.bodyWriter)
+
+	_, err = newDoParams(WithReplaceBody(strings.NewReader("replaced")), WithBytes(nil))
+	require.EqualError(t, err,
+		"body already exists: set by WithReplaceBody, cannot apply WithBytes")
+}
+
+func Test_WithBodyFromFile(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = fmt.Fprintf(w, "%d %s %s",
+			r.ContentLength, r.Header.Get(string(HeaderContentType)), body)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"a":1}`), 0o600))
+	emptyPath := filepath.Join(dir, "empty.txt")
+	require.NoError(t, os.WriteFile(emptyPath, nil, 0o600))
+
+	var file *os.File
+	captureFile := func(params *doParams) error {
+		file = params.bodyFile
+		return nil
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "detected content type",
+			opts: []Option{WithBodyFromFile(path), captureFile},
+			want: `7 application/json {"a":1}`,
+		},
+		{
+			name: "explicit content type",
+			opts: []Option{WithBodyFromFile(path), WithContentType("text/plain"), captureFile},
+			want: `7 text/plain {"a":1}`,
+		},
+		{
+			name: "empty file",
+			opts: []Option{WithBodyFromFile(emptyPath), captureFile},
+			want: "0 text/plain; charset=utf-8 ",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var got strings.Builder
+			err := Post(server.URL, append(tt.opts, WithOK().ToWriter(&got))...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+			assert.ErrorIs(t, file.Close(), os.ErrClosed)
+		})
+	}
+
+	err := Do("BAD METHOD", server.URL, WithBodyFromFile(path), captureFile)
+	require.ErrorIs(t, err, ErrInvalidHTTPMethod)
+	require.ErrorIs(t, file.Close(), os.ErrClosed)
+
+	_, err = newDoParams(WithBodyFromFile(path), captureFile, WithBytes(nil))
+	require.ErrorIs(t, err, ErrBodyAlreadyExists)
+	require.ErrorIs(t, file.Close(), os.ErrClosed)
+
+	err = Post(server.URL, WithBodyFromFile(filepath.Join(dir, "missing")))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func Test_WithChunkedBody(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = fmt.Fprintf(w, "%d %v %s", r.ContentLength, r.TransferEncoding, body)
+	}))
+	defer server.Close()
+
+	var got strings.Builder
+	err := Post(server.URL,
+		WithChunkedBody(strings.NewReader("data")),
+		WithOK().ToWriter(&got),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "-1 [chunked] data", got.String())
+
+	_, err = newDoParams(WithBodyFromFile("request_test.go"), WithChunkedBody(nil))
+	require.ErrorIs(t, err, ErrBodyAlreadyExists)
+}
+
+func Test_WithTrailer(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		_, _ = io.WriteString(w, r.Trailer.Get("X-Checksum"))
+	}))
+	defer server.Close()
+
+	var got strings.Builder
+	err := Post(server.URL,
+		WithChunkedBody(strings.NewReader("data")),
+		WithTrailer("x-checksum"),
+		WithHandlerBeforeResponse(func(req *http.Request) error {
+			req.Trailer.Set("X-Checksum", "abc")
+			return nil
+		}),
+		WithOK().ToWriter(&got),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", got.String())
+}
+
+func Test_WithResponseTrailer(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Result")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_, _ = io.WriteString(w, strings.Repeat("x", 1<<10))
+		w.Header().Set("X-Result", "done")
+	}))
+	defer server.Close()
+
+	var trailers []string
+	handler := WithResponseTrailer(func(trailer http.Header) error {
+		trailers = append(trailers, trailer.Get("X-Result"))
+		return nil
+	})
+
+	// The rest of the body not read by the handler is discarded,
+	// so the trailers are populated.
+	err := Get(server.URL,
+		handler,
+		WithOK().To(new(string), func(io.Reader, any) error { return nil }),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"done"}, trailers)
+
+	err = Get(server.URL+"/missing", handler, WithOK().ToDiscard())
+	require.ErrorIs(t, err, ErrUnhandledStatus(http.StatusNotFound))
+	assert.Len(t, trailers, 1)
+
+	errTrailer := errors.New("bad trailer")
+	err = Get(server.URL,
+		WithResponseTrailer(func(http.Header) error { return errTrailer }),
+		WithOK().ToDiscard(),
+	)
+	require.ErrorIs(t, err, errTrailer)
+}
+
+func Test_validateMethod(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Method)
+	}))
+	defer server.Close()
+
+	err := Do("GTE", server.URL)
+	require.ErrorIs(t, err, ErrInvalidHTTPMethod)
+	require.EqualError(t, err, `rqx: invalid HTTP method "GTE"`)
+
+	var got strings.Builder
+	err = Do("PURGE", server.URL, WithAllowCustomMethod(), WithOK().ToWriter(&got))
+	require.NoError(t, err)
+	assert.Equal(t, "PURGE", got.String())
+
+	err = Do(PROPFIND, server.URL, WithExpectStatus(http.StatusOK))
+	require.NoError(t, err)
+
+	err = Do("HEAD", server.URL, WithExpectStatus(http.StatusOK))
+	require.NoError(t, err)
+
+	err = Do("BAD METHOD", server.URL, WithAllowCustomMethod())
+	assert.ErrorIs(t, err, ErrInvalidHTTPMethod)
+}
+
+func Test_WithMethodOverride(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		method HTTPMethod
+		want   string
+	}{
+		{method: PUT, want: "POST PUT"},
+		{method: PATCH, want: "POST PATCH"},
+		{method: DELETE, want: "POST DELETE"},
+		{method: GET, want: "GET "},
+		{method: POST, want: "POST "},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(string(tt.method), func(t *testing.T) {
+			t.Parallel()
+
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				override := r.Header.Get(string(HeaderXHTTPMethodOverride))
+				_, _ = w.Write([]byte(r.Method + " " + override))
+			}
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			var got strings.Builder
+			err := Do(tt.method, server.URL, WithMethodOverride(), WithOK().ToWriter(&got))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func Test_bodyContentType(t *testing.T) {
+	t.Parallel()
+
+	const vndJSON = "application/vnd.api+json"
+
+	data := map[string]int{"a": 1}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "body option only",
+			opts: []Option{WithJSON(data)},
+			want: string(ContentJSON),
+		},
+		{
+			name: "explicit before body",
+			opts: []Option{WithContentType(vndJSON), WithJSON(data)},
+			want: vndJSON,
+		},
+		{
+			name: "explicit after body",
+			opts: []Option{WithJSON(data), WithContentType(vndJSON)},
+			want: vndJSON,
+		},
+		{
+			name: "manual header before body",
+			opts: []Option{WithHeader("content-type", vndJSON), WithJSON(data)},
+			want: vndJSON,
+		},
+		{
+			name: "manual header after body",
+			opts: []Option{WithTextPlain("text"), WithHeader("Content-Type", vndJSON)},
+			want: vndJSON,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			params, err := newDoParams(tt.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, []string{tt.want}, params.headers[string(HeaderContentType)])
+		})
+	}
+}
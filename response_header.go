@@ -0,0 +1,101 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+const headerTag = "header"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+var errHeadersDest = errors.New("headers destination must be a non-nil pointer to struct")
+
+// HeaderFieldError is an error for the struct field that cannot be populated
+// from the response header, see [WithHeadersInto].
+type HeaderFieldError struct {
+	Field  string
+	Header string
+	Err    error
+}
+
+func (e *HeaderFieldError) Error() string {
+	return fmt.Sprintf("cannot populate field %s from header %s: %v", e.Field, e.Header, e.Err)
+}
+
+func (e *HeaderFieldError) Unwrap() error {
+	return e.Err
+}
+
+var _ error = (*HeaderFieldError)(nil)
+
+// headersInto populates the fields of the struct pointed to by the given
+// value from the given header.
+func headersInto(dest reflect.Value, header http.Header) error {
+	structValue := dest.Elem()
+	structType := structValue.Type()
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+
+		name, ok := field.Tag.Lookup(headerTag)
+		if !ok || name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		values := header.Values(textproto.CanonicalMIMEHeaderKey(name))
+		if len(values) == 0 {
+			continue
+		}
+
+		if err := setHeaderField(structValue.Field(i), values); err != nil {
+			return &HeaderFieldError{Field: field.Name, Header: name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func setHeaderField(field reflect.Value, values []string) error {
+	if field.Type() == timeType {
+		t, err := http.ParseTime(values[0])
+		if err != nil {
+			return err
+		}
+
+		field.Set(reflect.ValueOf(t))
+
+		return nil
+	}
+
+	switch kind := field.Kind(); {
+	case kind == reflect.String:
+		field.SetString(values[0])
+	case field.CanInt():
+		n, err := strconv.ParseInt(values[0], 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case field.CanUint() && kind != reflect.Uintptr:
+		n, err := strconv.ParseUint(values[0], 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case kind == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		field.Set(reflect.ValueOf(append([]string(nil), values...)).Convert(field.Type()))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_headersInto(t *testing.T) {
+	t.Parallel()
+
+	type headers struct {
+		RequestID string    `header:"X-Request-Id"`
+		Remaining int       `header:"x-ratelimit-remaining"`
+		Limit     uint16    `header:"X-RateLimit-Limit"`
+		Date      time.Time `header:"Date"`
+		Vary      []string  `header:"Vary"`
+		Missing   string    `header:"X-Missing"`
+		Untagged  string
+	}
+
+	header := make(http.Header)
+	header.Set("X-Request-Id", "abc")
+	header.Set("X-Ratelimit-Remaining", "42")
+	header.Set("X-Ratelimit-Limit", "100")
+	header.Set("Date", "Sun, 06 Nov 1994 08:49:37 GMT")
+	header.Add("Vary", "Accept")
+	header.Add("Vary", "Accept-Encoding")
+
+	var got headers
+	require.NoError(t, headersInto(reflect.ValueOf(&got), header))
+
+	assert.Equal(t, headers{
+		RequestID: "abc",
+		Remaining: 42,
+		Limit:     100,
+		Date:      time.Date(1994, time.November, 6, 8, 49, 37, 0, time.UTC),
+		Vary:      []string{"Accept", "Accept-Encoding"},
+	}, got)
+
+	header.Set("X-Ratelimit-Remaining", "many")
+
+	var fieldErr *HeaderFieldError
+	require.ErrorAs(t, headersInto(reflect.ValueOf(&got), header), &fieldErr)
+	assert.Equal(t, "Remaining", fieldErr.Field)
+}
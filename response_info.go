@@ -0,0 +1,28 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"net/http"
+)
+
+// ResponseInfo holds the details of the response, see [WithResponseInfo].
+type ResponseInfo struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Proto is the negotiated protocol, e.g., "HTTP/1.1" or "HTTP/2.0".
+	Proto string
+
+	// Header is the HTTP header of the response.
+	Header http.Header
+}
+
+func newResponseInfo(resp *http.Response) ResponseInfo {
+	return ResponseInfo{
+		StatusCode: resp.StatusCode,
+		Proto:      resp.Proto,
+		Header:     resp.Header.Clone(),
+	}
+}
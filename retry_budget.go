@@ -0,0 +1,112 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRetryBudgetExhausted is returned along with the original error when
+// the request cannot be retried, because [RetryBudget] is exhausted.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryBudget bounds the number of retries relative to the number of requests
+// sharing the same budget, so retries do not multiply the load during an outage.
+// Share the same budget across requests using [WithRetryBudget].
+// It is safe for concurrent use.
+type RetryBudget struct {
+	mu         sync.Mutex
+	ratio      float64
+	minRetries int
+	window     time.Duration
+	start      time.Time
+	requests   int
+	retries    int
+	stats      RetryBudgetStats
+}
+
+// RetryBudgetStats are the counters of [RetryBudget] since its creation.
+type RetryBudgetStats struct {
+	// Requests is the number of requests that consulted the budget.
+	Requests uint64
+	// Retries is the number of allowed retries.
+	Retries uint64
+	// Exhausted is the number of denied retries.
+	Exhausted uint64
+}
+
+// NewRetryBudget creates [RetryBudget] that allows retrying the given ratio
+// of requests per window, e.g., 0.1 and [time.Minute] allow 10% of requests
+// per minute to be retried. The minRetries retries per window are allowed
+// regardless of the ratio, so rare requests can still be retried. A negative
+// ratio or minRetries is treated as zero; if the window is less than or equal
+// to zero, it is a minute.
+func NewRetryBudget(ratio float64, window time.Duration, minRetries int) *RetryBudget {
+	if window <= 0 {
+		window = time.Minute
+	}
+
+	return &RetryBudget{
+		ratio:      max(ratio, 0),
+		minRetries: max(minRetries, 0),
+		window:     window,
+		start:      time.Now(),
+	}
+}
+
+// Stats returns the counters of the budget.
+func (b *RetryBudget) Stats() RetryBudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.stats
+}
+
+// deposit records the request allowing more retries.
+// It is a no-op for nil budget.
+func (b *RetryBudget) deposit() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.resetExpired()
+	b.requests++
+	b.stats.Requests++
+}
+
+// withdraw reports whether the retry is allowed, recording it if so.
+// It always allows retries for nil budget.
+func (b *RetryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.resetExpired()
+	allowed := max(int(b.ratio*float64(b.requests)), b.minRetries)
+	if b.retries >= allowed {
+		b.stats.Exhausted++
+		return false
+	}
+
+	b.retries++
+	b.stats.Retries++
+	return true
+}
+
+// resetExpired starts a new window if the current one has expired.
+func (b *RetryBudget) resetExpired() {
+	if now := time.Now(); now.Sub(b.start) >= b.window {
+		b.start = now
+		b.requests = 0
+		b.retries = 0
+	}
+}
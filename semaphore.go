@@ -0,0 +1,49 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+)
+
+// Semaphore bounds the number of requests in flight. Share the same
+// semaphore across requests using [WithConcurrencyLimit] or [WithAsyncPool].
+type Semaphore struct {
+	slots chan struct{}
+}
+
+// NewSemaphore creates [Semaphore] that allows at most n requests in flight.
+// If n is less than 1, it allows one request.
+func NewSemaphore(n int) *Semaphore {
+	if n < 1 {
+		n = 1
+	}
+
+	return &Semaphore{slots: make(chan struct{}, n)}
+}
+
+// acquire waits for a free slot or for the given context to be done.
+// It is a no-op for nil semaphore.
+func (s *Semaphore) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees the slot acquired by [Semaphore.acquire].
+// It is a no-op for nil semaphore.
+func (s *Semaphore) release() {
+	if s == nil {
+		return
+	}
+
+	<-s.slots
+}
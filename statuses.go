@@ -0,0 +1,61 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+// StatusClass is a class of HTTP response status codes given by their first
+// digit, e.g., [StatusClassSuccessful] for the 2xx status codes.
+type StatusClass int
+
+// Classes of HTTP response status codes.
+const (
+	StatusClassInformational StatusClass = iota + 1
+	StatusClassSuccessful
+	StatusClassRedirection
+	StatusClassClientError
+	StatusClassServerError
+)
+
+// Contains reports whether the given status code is in the class.
+func (c StatusClass) Contains(status int) bool {
+	return c.statusRange().contains(status)
+}
+
+// statusRange returns the half-open range of status codes in the class.
+func (c StatusClass) statusRange() statusRange {
+	return statusRange{from: int(c) * 100, to: int(c)*100 + 100}
+}
+
+// statusRange is a half-open range [from, to) of HTTP response status codes.
+type statusRange struct {
+	from int
+	to   int
+}
+
+func (r statusRange) contains(status int) bool {
+	return r.from <= status && status < r.to
+}
+
+// responseStatuses is a set of HTTP response status codes given by explicit
+// codes and ranges, so that a whole class of status codes does not have to be
+// expanded to a slice.
+type responseStatuses struct {
+	codes  []int
+	ranges []statusRange
+}
+
+func (s responseStatuses) contains(status int) bool {
+	for _, code := range s.codes {
+		if code == status {
+			return true
+		}
+	}
+
+	for _, r := range s.ranges {
+		if r.contains(status) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,133 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_responseStatuses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		statuses responseStatuses
+		match    []int
+		mismatch []int
+	}{
+		{
+			name:     "Empty",
+			statuses: responseStatuses{},
+			mismatch: []int{http.StatusOK, http.StatusNotFound},
+		},
+		{
+			name:     "Default OK",
+			statuses: responseStatuses(WithOK()),
+			match:    []int{http.StatusOK},
+			mismatch: []int{http.StatusCreated, http.StatusNotFound},
+		},
+		{
+			name:     "Explicit codes",
+			statuses: withStatuses(http.StatusBadRequest, http.StatusConflict),
+			match:    []int{http.StatusBadRequest, http.StatusConflict},
+			mismatch: []int{http.StatusOK, http.StatusNotFound},
+		},
+		{
+			name:     "2xx",
+			statuses: responseStatuses(WithOK2xx()),
+			match:    []int{200, 204, 299},
+			mismatch: []int{199, 300},
+		},
+		{
+			name:     "4xx",
+			statuses: responseStatuses(WithError4xx[error]()),
+			match:    []int{400, 404, 499},
+			mismatch: []int{399, 500},
+		},
+		{
+			name:     "5xx",
+			statuses: responseStatuses(WithError5xx[error]()),
+			match:    []int{500, 503, 599},
+			mismatch: []int{499, 600},
+		},
+		{
+			name:     "Empty range",
+			statuses: withStatusRange(300, 300),
+			mismatch: []int{299, 300, 301},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, status := range tt.match {
+				assert.True(t, tt.statuses.contains(status), status)
+			}
+			for _, status := range tt.mismatch {
+				assert.False(t, tt.statuses.contains(status), status)
+			}
+		})
+	}
+}
+
+func Test_StatusClass_Contains(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		class    StatusClass
+		match    []int
+		mismatch []int
+	}{
+		{
+			name:     "1xx",
+			class:    StatusClassInformational,
+			match:    []int{100, 199},
+			mismatch: []int{99, 200},
+		},
+		{
+			name:     "2xx",
+			class:    StatusClassSuccessful,
+			match:    []int{200, 299},
+			mismatch: []int{199, 300},
+		},
+		{
+			name:     "3xx",
+			class:    StatusClassRedirection,
+			match:    []int{300, 399},
+			mismatch: []int{299, 400},
+		},
+		{
+			name:     "4xx",
+			class:    StatusClassClientError,
+			match:    []int{400, 499},
+			mismatch: []int{399, 500},
+		},
+		{
+			name:     "5xx",
+			class:    StatusClassServerError,
+			match:    []int{500, 599},
+			mismatch: []int{499, 600},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, status := range tt.match {
+				assert.True(t, tt.class.Contains(status), status)
+			}
+			for _, status := range tt.mismatch {
+				assert.False(t, tt.class.Contains(status), status)
+			}
+		})
+	}
+}
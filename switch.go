@@ -0,0 +1,115 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Switch decodes the body of the response into one of several targets
+// depending on the status code, e.g., *Success for [net/http.StatusOK]
+// and *ValidationError for [net/http.StatusUnprocessableEntity], and reports
+// which case matched, see [Switch.Do]. The matched response is handled
+// successfully, even if its target is an error.
+type Switch struct {
+	cases []switchCase
+}
+
+type switchCase struct {
+	status int
+	target any
+
+	// decoder is nil for JSON, so [WithJSONUseNumber] is taken into account.
+	decoder Decoder
+}
+
+// NewSwitch creates an empty [Switch].
+func NewSwitch() *Switch {
+	return &Switch{}
+}
+
+// On adds the case decoding the body of the response with the given status
+// code by the given [Decoder] to the value pointed to by the given target.
+// The first added case for the status code wins.
+func (s *Switch) On(status int, target any, decoder Decoder) *Switch {
+	s.cases = append(s.cases, switchCase{status: status, target: target, decoder: decoder})
+	return s
+}
+
+// OnJSON works like [Switch.On] with the JSON decoder.
+func (s *Switch) OnJSON(status int, target any) *Switch {
+	return s.On(status, target, nil)
+}
+
+// OnXML works like [Switch.On] with the XML decoder.
+func (s *Switch) OnXML(status int, target any) *Switch {
+	return s.On(status, target, xmlDecoder)
+}
+
+// Do sends an HTTP request given [HTTPMethod], URL, and options, see [Do],
+// and returns the status code of the matched case, or 0 if no case matched.
+// The cases replace the handler set by [WithOK]. If any target is not
+// a non-nil pointer, it causes the [ErrInvalidResult] error before sending
+// the request.
+func (s *Switch) Do(httpMethod HTTPMethod, url string, opts ...Option) (int, error) {
+	var matched int
+
+	all := make([]Option, 0, len(opts)+1)
+	all = append(all, opts...)
+	all = append(all, s.option(&matched))
+
+	err := Do(httpMethod, url, all...)
+
+	return matched, err
+}
+
+// option returns the option setting the handler that decodes the body
+// by the matched case and stores its status code to the given one.
+func (s *Switch) option(matched *int) Option {
+	return func(params *doParams) error {
+		for _, c := range s.cases {
+			if err := checkResult(c.target); err != nil {
+				return fmt.Errorf("case %d: %w", c.status, err)
+			}
+		}
+
+		params.handler.okMediaType = ""
+		params.handler.okResponse = func(resp *http.Response) (any, error) {
+			c, ok := s.match(resp.StatusCode)
+			if !ok {
+				return nil, nil
+			}
+
+			if err := params.handler.validateBody(resp); err != nil {
+				return nil, err
+			}
+
+			decoder := c.decoder
+			if decoder == nil {
+				decoder = params.handler.decodeJSON
+			}
+
+			if err := params.handler.decode(decoder, resp.Body, c.target); err != nil {
+				return nil, err
+			}
+
+			*matched = c.status
+
+			return c.target, nil
+		}
+
+		return nil
+	}
+}
+
+func (s *Switch) match(status int) (switchCase, bool) {
+	for _, c := range s.cases {
+		if c.status == status {
+			return c, true
+		}
+	}
+
+	return switchCase{}, false
+}
@@ -0,0 +1,60 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Switch(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/invalid":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = io.WriteString(w, `{"message": "name is required"}`)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			_, _ = io.WriteString(w, `{"id": 42}`)
+		}
+	}))
+	defer server.Close()
+
+	type success struct {
+		ID int `json:"id"`
+	}
+
+	var ok success
+	var invalid apiError
+	sw := NewSwitch().
+		OnJSON(http.StatusOK, &ok).
+		OnJSON(http.StatusUnprocessableEntity, &invalid)
+
+	matched, err := sw.Do(POST, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, matched)
+	assert.Equal(t, success{ID: 42}, ok)
+
+	matched, err = sw.Do(POST, server.URL+"/invalid")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, matched)
+	assert.Equal(t, "name is required", invalid.Message)
+
+	matched, err = sw.Do(GET, server.URL+"/missing")
+	require.ErrorIs(t, err, ErrUnhandledStatus(http.StatusNotFound))
+	assert.Zero(t, matched)
+
+	matched, err = NewSwitch().OnJSON(http.StatusOK, ok).Do(GET, server.URL)
+	require.ErrorIs(t, err, ErrInvalidResult)
+	require.ErrorContains(t, err, "case 200")
+	assert.Zero(t, matched)
+}
@@ -0,0 +1,64 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// teeBody writes the response body to the writer while it is read,
+// including by draining the body before closing it, see [drainAndClose].
+type teeBody struct {
+	io.Reader
+	body   io.ReadCloser
+	writer *teeWriter
+}
+
+// teeWriter remembers the first write error, so it is returned on close
+// even if the read error has been ignored, e.g., while draining the body.
+type teeWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (t *teeWriter) Write(p []byte) (int, error) {
+	n, err := t.w.Write(p)
+	if err != nil && t.err == nil {
+		t.err = err
+	}
+
+	return n, err
+}
+
+// teeResponse makes the body of the given response written to the given
+// writer. It is a no-op for nil writer.
+func teeResponse(resp *http.Response, w io.Writer) {
+	if w == nil {
+		return
+	}
+
+	writer := &teeWriter{w: w}
+	resp.Body = &teeBody{
+		Reader: io.TeeReader(resp.Body, writer),
+		body:   resp.Body,
+		writer: writer,
+	}
+}
+
+// Close closes the body. The write error is returned as [wrapperCloseError],
+// since the writer has not got the whole body.
+func (t *teeBody) Close() error {
+	closeErr := t.body.Close()
+
+	switch {
+	case t.writer.err == nil:
+		return closeErr
+	case closeErr == nil:
+		return &wrapperCloseError{err: t.writer.err}
+	default:
+		return errors.Join(&wrapperCloseError{err: t.writer.err}, closeErr)
+	}
+}
@@ -0,0 +1,128 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithTee(t *testing.T) {
+	t.Parallel()
+
+	const body = `{"id":42}` + "\n"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_, _ = io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	var got struct {
+		ID int `json:"id"`
+	}
+	var sink strings.Builder
+
+	err := Get(server.URL, WithTee(&sink), WithOK().ToJSON(&got))
+	require.NoError(t, err)
+	assert.Equal(t, 42, got.ID)
+	assert.Equal(t, body, sink.String())
+
+	sink.Reset()
+	err = Get(server.URL+"/missing", WithTee(&sink))
+	require.ErrorIs(t, err, ErrUnhandledStatus(http.StatusNotFound))
+	assert.Equal(t, body, sink.String())
+
+	sink.Reset()
+	err = Get(server.URL,
+		WithTee(&sink),
+		WithHandlerAfterResponse(func(*http.Response) error { return io.ErrUnexpectedEOF }),
+	)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, body, sink.String())
+
+	// The unread rest of the body is written only up to the drain limit.
+	sink.Reset()
+	err = Get(server.URL,
+		WithTee(&sink),
+		WithDrainOnClose(4),
+		WithHandlerAfterResponse(func(*http.Response) error { return io.ErrUnexpectedEOF }),
+	)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, body[:4], sink.String())
+
+	sink.Reset()
+	err = Get(server.URL,
+		WithTee(&sink),
+		WithNoDrain(),
+		WithHandlerAfterResponse(func(*http.Response) error { return io.ErrUnexpectedEOF }),
+	)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Empty(t, sink.String())
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func Test_WithTee_closeErrors(t *testing.T) {
+	t.Parallel()
+
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       failingCloseBody{strings.NewReader("ok")},
+			Request:    r,
+		}, nil
+	})
+	client := &http.Client{Transport: transport}
+	skipBody := func(io.Reader, any) error { return nil }
+
+	var (
+		result   struct{}
+		sink     strings.Builder
+		closeErr error
+	)
+
+	// The error of closing the body itself is ignored.
+	err := Get("https://example.com",
+		WithClient(client),
+		WithTee(&sink),
+		WithOK().To(&result, skipBody),
+		WithCloseErrorHandler(func(err error) { closeErr = err }),
+	)
+	require.NoError(t, err)
+	require.EqualError(t, closeErr, "close failed")
+	assert.Equal(t, "ok", sink.String())
+
+	// The error of copying the rest of the body is not.
+	err = Get("https://example.com",
+		WithClient(client),
+		WithTee(failingWriter{}),
+		WithOK().To(&result, skipBody),
+	)
+	require.ErrorContains(t, err, "write failed")
+	require.ErrorContains(t, err, "close failed")
+
+	// The write error returned by reading the body is not duplicated.
+	err = Get("https://example.com",
+		WithClient(client),
+		WithTee(failingWriter{}),
+		WithOK().ToDiscard(),
+	)
+	require.ErrorContains(t, err, "write failed")
+	assert.Equal(t, 1, strings.Count(err.Error(), "write failed"))
+}
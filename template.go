@@ -0,0 +1,46 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"net/http"
+
+	"github.com/tsayukov/optparams"
+)
+
+// RequestTemplate holds the options shared by many similar requests,
+// e.g., the same headers and authorization with different URLs.
+// It is safe for concurrent use.
+type RequestTemplate struct {
+	opts []Option
+}
+
+// Template creates [RequestTemplate] from the given options. The options
+// are applied once to report their errors before any request is sent.
+//
+// Each request built from the template gets its own copy of the parameters,
+// including the headers, so the options of one request and the handlers
+// mutating it do not leak to other requests. Note that the values shared
+// by the options themselves, e.g., [WithBody] readers or [WithOK] results,
+// are still shared, so pass them to [RequestTemplate.Do] instead.
+func Template(opts ...Option) (*RequestTemplate, error) {
+	params := &doParams{headers: make(http.Header)}
+	defer params.closeBodyFile()
+
+	if err := optparams.Apply(params, opts...); err != nil {
+		return nil, err
+	}
+
+	return &RequestTemplate{opts: append([]Option(nil), opts...)}, nil
+}
+
+// Do sends an HTTP request given [HTTPMethod], URL, and the template options
+// followed by the given extra ones, see [Do].
+func (t *RequestTemplate) Do(httpMethod HTTPMethod, url string, extra ...Option) error {
+	opts := make([]Option, 0, len(t.opts)+len(extra))
+	opts = append(opts, t.opts...)
+	opts = append(opts, extra...)
+
+	return Do(httpMethod, url, opts...)
+}
@@ -0,0 +1,58 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RequestTemplate(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Got", strings.Join(r.Header.Values("X-Tag"), ","))
+		w.Header().Set("X-Auth", r.Header.Get("Authorization"))
+		w.Header().Set("X-Path", r.URL.Path)
+	}))
+	defer server.Close()
+
+	template, err := Template(
+		WithAuth("Bearer token"),
+		WithHeader("X-Tag", "base"),
+	)
+	require.NoError(t, err)
+
+	var first, second ResponseInfo
+
+	err = template.Do(GET, server.URL,
+		WithURLPaths("first"),
+		WithHeader("X-Tag", "extra", HeaderAppendModeON),
+		WithRequestMutator(func(req *http.Request) { req.Header.Add("X-Tag", "mutated") }),
+		WithResponseInfo(&first),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.NoError(t, err)
+
+	err = template.Do(GET, server.URL,
+		WithURLPaths("second"),
+		WithResponseInfo(&second),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "base,extra,mutated", first.Header.Get("X-Got"))
+	assert.Equal(t, "/first", first.Header.Get("X-Path"))
+	assert.Equal(t, "base", second.Header.Get("X-Got"))
+	assert.Equal(t, "/second", second.Header.Get("X-Path"))
+	assert.Equal(t, "Bearer token", second.Header.Get("X-Auth"))
+
+	_, err = Template(WithBytes(nil), WithTextPlain(""))
+	assert.ErrorIs(t, err, ErrBodyAlreadyExists)
+}
@@ -0,0 +1,173 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// ClientTraceCallbacks are the optional callbacks called by the client
+// transport while sending the request, see [WithClientTrace].
+// They mirror the most useful hooks of [net/http/httptrace.ClientTrace]
+// and may be called from different goroutines.
+type ClientTraceCallbacks struct {
+	GetConn              func(hostPort string)
+	GotConn              func(info httptrace.GotConnInfo)
+	DNSStart             func(info httptrace.DNSStartInfo)
+	DNSDone              func(info httptrace.DNSDoneInfo)
+	ConnectStart         func(network, addr string)
+	ConnectDone          func(network, addr string, err error)
+	TLSHandshakeStart    func()
+	TLSHandshakeDone     func(state tls.ConnectionState, err error)
+	WroteRequest         func(info httptrace.WroteRequestInfo)
+	GotFirstResponseByte func()
+}
+
+func (c *ClientTraceCallbacks) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn:              c.GetConn,
+		GotConn:              c.GotConn,
+		DNSStart:             c.DNSStart,
+		DNSDone:              c.DNSDone,
+		ConnectStart:         c.ConnectStart,
+		ConnectDone:          c.ConnectDone,
+		TLSHandshakeStart:    c.TLSHandshakeStart,
+		TLSHandshakeDone:     c.TLSHandshakeDone,
+		WroteRequest:         c.WroteRequest,
+		GotFirstResponseByte: c.GotFirstResponseByte,
+	}
+}
+
+// Timings are the durations of the request phases, see [WithTimingInto].
+// The phases not taken, e.g., when the connection is reused, are zero.
+type Timings struct {
+	// DNSLookup is the time spent resolving the host.
+	DNSLookup time.Duration
+	// Connect is the time spent establishing the TCP connection.
+	Connect time.Duration
+	// TLSHandshake is the time spent on the TLS handshake.
+	TLSHandshake time.Duration
+	// TimeToFirstByte is the time from sending the request
+	// to receiving the first byte of the response.
+	TimeToFirstByte time.Duration
+	// Total is the time from sending the request to closing the response body.
+	Total time.Duration
+}
+
+// timingsRecorder records [Timings] of a single attempt.
+type timingsRecorder struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	timings      Timings
+}
+
+// newTimingsRecorder creates timingsRecorder if [Timings] is required,
+// otherwise it returns nil.
+func newTimingsRecorder(timings *Timings) *timingsRecorder {
+	if timings == nil {
+		return nil
+	}
+
+	return &timingsRecorder{}
+}
+
+func (r *timingsRecorder) clientTrace() *httptrace.ClientTrace {
+	since := func(start time.Time) time.Duration {
+		if start.IsZero() {
+			return 0
+		}
+
+		return time.Since(start)
+	}
+
+	record := func(fn func()) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		fn()
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			record(func() { r.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			record(func() { r.timings.DNSLookup = since(r.dnsStart) })
+		},
+		ConnectStart: func(string, string) {
+			record(func() {
+				// Several addresses may be tried, count from the first one.
+				if r.connectStart.IsZero() {
+					r.connectStart = time.Now()
+				}
+			})
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				record(func() { r.timings.Connect = since(r.connectStart) })
+			}
+		},
+		TLSHandshakeStart: func() {
+			record(func() { r.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			record(func() { r.timings.TLSHandshake = since(r.tlsStart) })
+		},
+		GotFirstResponseByte: func() {
+			record(func() { r.timings.TimeToFirstByte = since(r.start) })
+		},
+	}
+}
+
+// begin records the start time just before sending the request.
+// It is a no-op for nil recorder.
+func (r *timingsRecorder) begin() {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.start = time.Now()
+}
+
+// finish stores the recorded timings to the given destination.
+// It is a no-op for nil recorder.
+func (r *timingsRecorder) finish(timings *Timings) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.timings.Total = time.Since(r.start)
+	*timings = r.timings
+}
+
+// withClientTraces returns a copy of the given context with the given traces
+// composed with the trace already present in the context, if any.
+func withClientTraces(
+	ctx context.Context,
+	traces []*ClientTraceCallbacks,
+	recorder *timingsRecorder,
+) context.Context {
+	for _, trace := range traces {
+		ctx = httptrace.WithClientTrace(ctx, trace.clientTrace())
+	}
+
+	if recorder != nil {
+		ctx = httptrace.WithClientTrace(ctx, recorder.clientTrace())
+	}
+
+	return ctx
+}
@@ -0,0 +1,71 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithClientTrace(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	var fromContext, fromOption, firstByte atomic.Int32
+
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) { fromContext.Add(1) },
+	})
+
+	err := Get(server.URL,
+		WithContext(ctx),
+		WithClientTrace(ClientTraceCallbacks{
+			GotConn: func(httptrace.GotConnInfo) { fromOption.Add(1) },
+		}),
+		WithClientTrace(ClientTraceCallbacks{
+			GotFirstResponseByte: func() { firstByte.Add(1) },
+		}),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), fromContext.Load())
+	assert.Equal(t, int32(1), fromOption.Load())
+	assert.Equal(t, int32(1), firstByte.Load())
+}
+
+func Test_WithTimingInto(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	var timings Timings
+
+	err := Get(server.URL,
+		WithClient(server.Client()),
+		WithTimingInto(&timings),
+		WithExpectStatus(http.StatusOK),
+	)
+	require.NoError(t, err)
+	assert.Zero(t, timings.DNSLookup) // the host is an IP address
+	assert.Positive(t, timings.Connect)
+	assert.Positive(t, timings.TLSHandshake)
+	assert.GreaterOrEqual(t, timings.TimeToFirstByte, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, timings.Total, timings.TimeToFirstByte)
+
+	err = Get(server.URL, WithTimingInto(nil))
+	assert.Error(t, err)
+}
@@ -0,0 +1,280 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// TimeoutLimit is the limit that expired and caused [TimeoutError].
+type TimeoutLimit string
+
+const (
+	// TimeoutUnknown is used when the expired limit cannot be determined,
+	// e.g., the dial timeout or a network timeout.
+	TimeoutUnknown TimeoutLimit = ""
+
+	// TimeoutContextDeadline is used when the deadline of the request
+	// context is exceeded.
+	TimeoutContextDeadline TimeoutLimit = "context deadline"
+
+	// TimeoutClient is used when [net/http.Client.Timeout] elapsed.
+	TimeoutClient TimeoutLimit = "client timeout"
+
+	// TimeoutResponseHeader is used when
+	// [net/http.Transport.ResponseHeaderTimeout] elapsed.
+	TimeoutResponseHeader TimeoutLimit = "response header timeout"
+
+	// TimeoutBodyRead is used when the timeout set by [WithBodyReadTimeout]
+	// elapsed.
+	TimeoutBodyRead TimeoutLimit = "body read timeout"
+)
+
+// TimeoutError is an error for the request that timed out, e.g., the context
+// deadline exceeded or the client timeout elapsed.
+type TimeoutError struct {
+	// Limit is the limit that expired.
+	Limit TimeoutLimit
+
+	// Elapsed is the time elapsed since sending the request.
+	Elapsed time.Duration
+
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Limit == TimeoutUnknown {
+		return fmt.Sprintf("request timed out after %s: %v", e.Elapsed, e.Err)
+	}
+
+	return fmt.Sprintf("request timed out after %s (%s expired): %v", e.Elapsed, e.Limit, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// ConnectionError is an error for the request that failed to connect
+// to the server or lost the connection, e.g., the connection was refused
+// or reset.
+type ConnectionError struct {
+	Err error
+}
+
+func (e *ConnectionError) Error() string {
+	return "connection failed: " + e.Err.Error()
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// DNSError is an error for the request whose host could not be resolved.
+type DNSError struct {
+	Err error
+}
+
+func (e *DNSError) Error() string {
+	return "DNS lookup failed: " + e.Err.Error()
+}
+
+func (e *DNSError) Unwrap() error {
+	return e.Err
+}
+
+// TLSError is an error for the request that failed the TLS handshake,
+// e.g., the server certificate could not be verified.
+type TLSError struct {
+	Err error
+}
+
+func (e *TLSError) Error() string {
+	return "TLS handshake failed: " + e.Err.Error()
+}
+
+func (e *TLSError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	_ error = (*TimeoutError)(nil)
+	_ error = (*ConnectionError)(nil)
+	_ error = (*DNSError)(nil)
+	_ error = (*TLSError)(nil)
+)
+
+// IsTimeout reports whether the given error is caused by the request timeout.
+func IsTimeout(err error) bool {
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return true
+	}
+
+	return isTimeout(err)
+}
+
+// IsConnectionRefused reports whether the given error is caused by the server
+// refusing the connection.
+func IsConnectionRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// idempotentHTTPMethods are the methods whose request can be sent again
+// with the same effect, so it can be retried after the connection is closed
+// before the response.
+var idempotentHTTPMethods = append([]HTTPMethod{PUT, DELETE}, safeHTTPMethods...)
+
+// IsRetryableNetErr reports whether the given error returned by [Do] is caused
+// by a transient network failure, so the request can be retried:
+//   - the timeout, except the deadline of the request context;
+//   - the connection refused, reset, or aborted, or the broken pipe;
+//   - the temporary DNS failure;
+//   - [io.EOF] or [io.ErrUnexpectedEOF] of the connection closed before
+//     the response of the idempotent request, e.g., GET or PUT.
+//
+// The canceled context, the host not found, and the TLS errors are permanent.
+// Compose it with other conditions to make a custom retry predicate.
+func IsRetryableNetErr(err error) bool {
+	var (
+		timeoutErr *TimeoutError
+		dnsErr     *net.DNSError
+	)
+
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), isTLSError(err):
+		return false
+	case errors.As(err, &timeoutErr):
+		return timeoutErr.Limit != TimeoutContextDeadline
+	case errors.As(err, &dnsErr):
+		return !dnsErr.IsNotFound && (dnsErr.IsTemporary || dnsErr.IsTimeout)
+	case isTimeout(err):
+		return true
+	case errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.EPIPE):
+		return true
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		// The client reports the method as the operation, e.g., "Get".
+		var urlErr *url.Error
+		return errors.As(err, &urlErr) &&
+			slices.Contains(idempotentHTTPMethods, HTTPMethod(strings.ToUpper(urlErr.Op)))
+	default:
+		return false
+	}
+}
+
+// classifyTransportError wraps the given error returned by
+// [net/http.Client.Do] in one of [DNSError], [TLSError], [TimeoutError],
+// and [ConnectionError], if the error chain allows to determine it.
+// Otherwise, the error is returned as is.
+//
+// The request context, the client, and the time elapsed since sending
+// the request are used to determine which limit expired in [TimeoutError].
+func classifyTransportError(
+	ctx context.Context,
+	err error,
+	client *http.Client,
+	elapsed time.Duration,
+) error {
+	switch {
+	case isDNSError(err):
+		return &DNSError{Err: err}
+	case isTLSError(err):
+		return &TLSError{Err: err}
+	case isTimeout(err):
+		return &TimeoutError{
+			Limit:   expiredTimeoutLimit(ctx, client, elapsed),
+			Elapsed: elapsed,
+			Err:     err,
+		}
+	case isConnectionError(err):
+		return &ConnectionError{Err: err}
+	default:
+		return err
+	}
+}
+
+// expiredTimeoutLimit determines which limit expired. The client timeout
+// does not cancel the request context, so the context deadline is expired
+// only if the context reports it.
+func expiredTimeoutLimit(
+	ctx context.Context,
+	client *http.Client,
+	elapsed time.Duration,
+) TimeoutLimit {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return TimeoutContextDeadline
+	}
+
+	if client.Timeout > 0 && elapsed >= client.Timeout {
+		return TimeoutClient
+	}
+
+	transport, ok := client.Transport.(*http.Transport)
+	if client.Transport == nil {
+		transport, ok = http.DefaultTransport.(*http.Transport)
+	}
+
+	if ok && transport.ResponseHeaderTimeout > 0 && elapsed >= transport.ResponseHeaderTimeout {
+		return TimeoutResponseHeader
+	}
+
+	return TimeoutUnknown
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func isDNSError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+func isTLSError(err error) bool {
+	var (
+		recordHeaderErr     tls.RecordHeaderError
+		alertErr            tls.AlertError
+		certVerificationErr *tls.CertificateVerificationError
+		unknownAuthorityErr x509.UnknownAuthorityError
+		hostnameErr         x509.HostnameError
+		certInvalidErr      x509.CertificateInvalidError
+	)
+
+	return errors.As(err, &recordHeaderErr) ||
+		errors.As(err, &alertErr) ||
+		errors.As(err, &certVerificationErr) ||
+		errors.As(err, &unknownAuthorityErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &certInvalidErr)
+}
+
+func isConnectionError(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) {
+		return true
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
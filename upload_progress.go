@@ -0,0 +1,107 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// UploadProgress is the progress of sending the request body,
+// see [WithUploadProgress].
+type UploadProgress struct {
+	// Sent is the number of bytes of the body read by the client
+	// in the current attempt.
+	Sent int64
+
+	// Total is the length of the body, or -1 if unknown.
+	Total int64
+
+	// Attempt is the number of the attempt starting from 1, so the values
+	// greater than 1 mean that the request is being retried.
+	Attempt int
+}
+
+// uploadProgress holds the settings of [WithUploadProgress].
+type uploadProgress struct {
+	report   func(UploadProgress)
+	every    int64
+	interval time.Duration
+}
+
+// track makes the body of the given request report its progress, keeping
+// its length. It is a no-op for nil progress.
+func (u *uploadProgress) track(req *http.Request, attempt int) {
+	if u == nil || req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	total := req.ContentLength
+	if total == 0 {
+		total = -1 // the body is not empty, so its length is unknown
+	}
+
+	req.Body = u.newBody(req.Body, total, attempt)
+
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil || body == http.NoBody {
+				return body, err
+			}
+
+			return u.newBody(body, total, attempt), nil
+		}
+	}
+}
+
+func (u *uploadProgress) newBody(body io.ReadCloser, total int64, attempt int) *progressBody {
+	return &progressBody{
+		ReadCloser: body,
+		settings:   u,
+		progress:   UploadProgress{Total: total, Attempt: attempt},
+		reportedAt: time.Now(),
+	}
+}
+
+// progressBody reports the number of bytes read from it at most every
+// settings.every bytes or settings.interval, and once the body is read.
+type progressBody struct {
+	io.ReadCloser
+	settings   *uploadProgress
+	progress   UploadProgress
+	reported   int64
+	reportedAt time.Time
+}
+
+func (b *progressBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.progress.Sent += int64(n)
+
+	done := errors.Is(err, io.EOF) || b.progress.Sent == b.progress.Total
+	if b.progress.Sent != b.reported && (done || b.due()) {
+		b.reported = b.progress.Sent
+		if b.settings.interval > 0 {
+			b.reportedAt = time.Now()
+		}
+
+		b.settings.report(b.progress)
+	}
+
+	return n, err
+}
+
+// due reports whether enough bytes have been read or enough time has passed
+// since the last report. If neither is set, every read is reported.
+func (b *progressBody) due() bool {
+	every, interval := b.settings.every, b.settings.interval
+	if every <= 0 && interval <= 0 {
+		return true
+	}
+
+	return (every > 0 && b.progress.Sent-b.reported >= every) ||
+		(interval > 0 && time.Since(b.reportedAt) >= interval)
+}
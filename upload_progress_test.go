@@ -0,0 +1,92 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithUploadProgress(t *testing.T) {
+	t.Parallel()
+
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	var (
+		mu      sync.Mutex
+		reports []UploadProgress
+	)
+	progress := WithUploadProgress(func(p UploadProgress) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, p)
+	}, 4<<10, 0)
+
+	data := bytes.Repeat([]byte("x"), 10<<10)
+	err := Post(server.URL,
+		WithBytes(data),
+		progress,
+		WithRetryOnUnauthorized(func(context.Context, *http.Response) error { return nil }),
+		WithOK().ToDiscard(),
+	)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.NotEmpty(t, reports)
+	last := reports[len(reports)-1]
+	assert.Equal(t, UploadProgress{Sent: 10 << 10, Total: 10 << 10, Attempt: 2}, last)
+
+	var firstAttempt []UploadProgress
+	for _, p := range reports {
+		assert.Equal(t, int64(10<<10), p.Total)
+		if p.Attempt == 1 {
+			firstAttempt = append(firstAttempt, p)
+		}
+	}
+	require.NotEmpty(t, firstAttempt)
+	assert.Equal(t, int64(10<<10), firstAttempt[len(firstAttempt)-1].Sent)
+	for i := 1; i < len(firstAttempt); i++ {
+		assert.GreaterOrEqual(t, firstAttempt[i].Sent-firstAttempt[i-1].Sent, int64(4<<10))
+	}
+}
+
+func Test_WithUploadProgress_unknownTotal(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+	}))
+	defer server.Close()
+
+	var last UploadProgress
+	err := Post(server.URL,
+		WithBody(io.MultiReader(strings.NewReader("abc"), strings.NewReader("def"))),
+		WithUploadProgress(func(p UploadProgress) { last = p }, 0, 0),
+		WithOK().ToDiscard(),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, UploadProgress{Sent: 6, Total: -1, Attempt: 1}, last)
+
+	err = Post(server.URL, WithUploadProgress(nil, 0, 0))
+	require.Error(t, err)
+}
@@ -4,8 +4,15 @@
 package rqx
 
 import (
+	"errors"
+	"fmt"
+	urlpkg "net/url"
+	"reflect"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	querypkg "github.com/google/go-querystring/query"
 )
@@ -26,10 +33,122 @@ func FromUint[T interface {
 	return strconv.FormatUint(uint64(value), 10)
 }
 
+// pendingQuery is the data reserved by deferQuery or deferValues.
+type pendingQuery struct {
+	data any
+
+	// values are already encoded, e.g., by [WithQueryFromMap], and used
+	// instead of data if not nil.
+	values urlpkg.Values
+
+	// indexed reports whether the bracketed keys are indexed,
+	// see indexBrackets.
+	indexed bool
+}
+
+// QueryParamError is an error for the query parameter that cannot be encoded,
+// see [WithQueryFromMap].
+type QueryParamError struct {
+	Key string
+	Err error
+}
+
+func (e *QueryParamError) Error() string {
+	return fmt.Sprintf("query parameter %q: %v", e.Key, e.Err)
+}
+
+func (e *QueryParamError) Unwrap() error {
+	return e.Err
+}
+
+var _ error = (*QueryParamError)(nil)
+
+// QueryEncoder encodes the given data into [net/url.Values],
+// see [WithQueryEncoder].
+type QueryEncoder func(data any) (urlpkg.Values, error)
+
 type urlBuilder struct {
 	length  int
 	paths   []string
 	queries []string
+
+	// pending holds the data to be encoded into the queries at the same
+	// indices by resolveQueries, so the encoder does not depend on the order
+	// of the options.
+	pending     map[int]pendingQuery
+	encoder     QueryEncoder
+	skipEmpty   bool
+	arrayFormat ArrayFormat
+
+	allowDuplicateQueryKeys bool
+}
+
+// prepare encodes the deferred queries and checks the duplicate query keys
+// after all the options are applied.
+func (u *urlBuilder) prepare() error {
+	if err := u.resolveQueries(); err != nil {
+		return err
+	}
+
+	return u.checkDuplicateQueryKeys()
+}
+
+// BuildURL returns the URL that [Do] would send the request to given the base
+// URL and the options, without sending the request, e.g., to check the URL
+// in tests. The options are applied as by [Do], sharing the same code path,
+// so their errors are returned, but only the URL options, e.g., [WithURLPaths]
+// and [WithQuery], affect the result.
+func BuildURL(base string, opts ...Option) (string, error) {
+	params, err := applyOptions(opts)
+	defer params.closeBodyFile()
+
+	if err != nil {
+		return "", err
+	}
+
+	return params.buildURL(base)
+}
+
+// ErrDuplicateQueryKeys is returned when the same query key is added by
+// several query options, unless [WithAllowDuplicateQueryKeys] is set.
+var ErrDuplicateQueryKeys = errors.New("duplicate query keys")
+
+// checkDuplicateQueryKeys returns the [ErrDuplicateQueryKeys] error listing
+// the keys that appear in more than one query string. Repeated keys within
+// the same query string, e.g., encoded from a slice, are allowed.
+func (u *urlBuilder) checkDuplicateQueryKeys() error {
+	if u.allowDuplicateQueryKeys || len(u.queries) < 2 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	duplicates := make(map[string]bool)
+
+	for _, query := range u.queries {
+		values, err := urlpkg.ParseQuery(query)
+		if err != nil {
+			return err
+		}
+
+		for key := range values {
+			if seen[key] {
+				duplicates[key] = true
+			}
+			seen[key] = true
+		}
+	}
+
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(duplicates))
+	for key := range duplicates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return fmt.Errorf("%w: %s", ErrDuplicateQueryKeys, strings.Join(keys, ", "))
 }
 
 func (u *urlBuilder) appendPaths(paths ...string) error {
@@ -47,18 +166,296 @@ func (u *urlBuilder) appendQuery(data any) error {
 		return nil
 	}
 
-	values, err := querypkg.Values(data)
+	values, err := u.encodeValues(data, false)
 	if err != nil {
 		return err
 	}
 
-	query := values.Encode()
+	query := u.formatQuery(values)
 	u.length += 1 + len(query)
 	u.queries = append(u.queries, query)
 
 	return nil
 }
 
+// deferQuery reserves the place for the query encoded from the given data
+// by resolveQueries.
+func (u *urlBuilder) deferQuery(data any, indexed bool) {
+	if data == nil {
+		return
+	}
+
+	u.deferPending(pendingQuery{data: data, indexed: indexed})
+}
+
+// deferValues reserves the place for the query formatted from the given
+// values by resolveQueries.
+func (u *urlBuilder) deferValues(values urlpkg.Values) {
+	u.deferPending(pendingQuery{values: values})
+}
+
+func (u *urlBuilder) deferPending(pending pendingQuery) {
+	if u.pending == nil {
+		u.pending = make(map[int]pendingQuery)
+	}
+
+	u.pending[len(u.queries)] = pending
+	u.queries = append(u.queries, "")
+}
+
+// resolveQueries encodes the data reserved by deferQuery and formats
+// the values reserved by deferValues.
+func (u *urlBuilder) resolveQueries() error {
+	for index, pending := range u.pending {
+		values := pending.values
+		switch {
+		case values == nil:
+			var err error
+			values, err = u.encodeValues(pending.data, pending.indexed)
+			if err != nil {
+				return err
+			}
+		case u.skipEmpty:
+			values = skipEmptyValues(values)
+		}
+
+		query := u.formatQuery(values)
+		u.length += 1 + len(query)
+		u.queries[index] = query
+	}
+
+	u.pending = nil
+
+	return nil
+}
+
+func (u *urlBuilder) encodeValues(data any, indexed bool) (urlpkg.Values, error) {
+	encode := u.encoder
+	if encode == nil {
+		encode = querypkg.Values
+	}
+
+	values, err := encode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.skipEmpty {
+		values = skipEmptyValues(values)
+	}
+
+	if indexed {
+		values = indexBrackets(values)
+	}
+
+	return values, nil
+}
+
+// skipEmptyValues removes the empty values and the keys left without values,
+// e.g., "q=&page=1" becomes "page=1".
+func skipEmptyValues(values urlpkg.Values) urlpkg.Values {
+	for key, vs := range values {
+		vs = slices.DeleteFunc(vs, func(v string) bool { return v == "" })
+		if len(vs) == 0 {
+			delete(values, key)
+			continue
+		}
+		values[key] = vs
+	}
+
+	return values
+}
+
+// indexBrackets replaces the empty brackets of the keys with the indices
+// of the values, e.g., "ids[]=1&ids[]=2" becomes "ids[0]=1&ids[1]=2".
+func indexBrackets(values urlpkg.Values) urlpkg.Values {
+	indexed := make(urlpkg.Values, len(values))
+
+	for key, vs := range values {
+		name, ok := strings.CutSuffix(key, "[]")
+		if !ok {
+			indexed[key] = vs
+			continue
+		}
+
+		for i, v := range vs {
+			indexed.Add(name+"["+strconv.Itoa(i)+"]", v)
+		}
+	}
+
+	return indexed
+}
+
+// appendQueryArray adds the single key with the given values joined by
+// the given delimiter. The values are escaped, so the delimiters inside them
+// are distinguishable from the delimiters between them. The delimiter
+// is escaped by queryDelimiterReplacer.
+func (u *urlBuilder) appendQueryArray(key string, values []string, delim string) {
+	if len(values) == 0 {
+		return
+	}
+
+	escaped := make([]string, len(values))
+	for i, value := range values {
+		escaped[i] = urlpkg.QueryEscape(value)
+	}
+
+	query := urlpkg.QueryEscape(key) + "=" + strings.Join(escaped, escapeQueryDelimiter(delim))
+	u.length += 1 + len(query)
+	u.queries = append(u.queries, query)
+}
+
+// queryDelimiterReplacer keeps the comma and the pipe of the escaped
+// delimiter, and replaces '+' with "%20" for the space, as in the OpenAPI
+// form, spaceDelimited, and pipeDelimited styles.
+var queryDelimiterReplacer = strings.NewReplacer("+", "%20", "%2C", ",", "%7C", "|")
+
+func escapeQueryDelimiter(delim string) string {
+	return queryDelimiterReplacer.Replace(urlpkg.QueryEscape(delim))
+}
+
+// ArrayFormat is the format of the query keys with several values,
+// see [WithQueryArrayFormat].
+type ArrayFormat int
+
+const (
+	// ArrayFormatRepeat repeats the key for each value, e.g., "a=1&a=2".
+	ArrayFormatRepeat ArrayFormat = iota + 1
+
+	// ArrayFormatBrackets repeats the key with the empty brackets for each
+	// value, e.g., "a[]=1&a[]=2".
+	ArrayFormatBrackets
+
+	// ArrayFormatComma joins the values by the comma, e.g., "a=1,2".
+	ArrayFormatComma
+
+	// ArrayFormatIndex repeats the key with the index of each value,
+	// e.g., "a[0]=1&a[1]=2".
+	ArrayFormatIndex
+)
+
+// formatQuery encodes the given values sorted by key, like
+// [net/url.Values.Encode], in the format set by [WithQueryArrayFormat].
+// The keys with several values or the empty brackets are arrays.
+func (u *urlBuilder) formatQuery(values urlpkg.Values) string {
+	if u.arrayFormat == 0 {
+		return values.Encode()
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var query strings.Builder
+	add := func(key, escapedValue string) {
+		if query.Len() > 0 {
+			query.WriteByte('&')
+		}
+		query.WriteString(urlpkg.QueryEscape(key))
+		query.WriteByte('=')
+		query.WriteString(escapedValue)
+	}
+
+	for _, key := range keys {
+		vs := values[key]
+		name, bracketed := strings.CutSuffix(key, "[]")
+
+		escaped := make([]string, len(vs))
+		for i, v := range vs {
+			escaped[i] = urlpkg.QueryEscape(v)
+		}
+
+		switch {
+		case !bracketed && len(vs) < 2, u.arrayFormat == ArrayFormatRepeat:
+			for _, v := range escaped {
+				add(name, v)
+			}
+		case u.arrayFormat == ArrayFormatBrackets:
+			for _, v := range escaped {
+				add(name+"[]", v)
+			}
+		case u.arrayFormat == ArrayFormatComma:
+			add(name, strings.Join(escaped, escapeQueryDelimiter(",")))
+		default:
+			for i, v := range escaped {
+				add(name+"["+strconv.Itoa(i)+"]", v)
+			}
+		}
+	}
+
+	return query.String()
+}
+
+func (u *urlBuilder) appendQueryFromMap(m map[string]any) error {
+	values := make(urlpkg.Values, len(m))
+
+	for key, value := range m {
+		if value == nil {
+			continue
+		}
+
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			s, err := stringifyQueryValue(rv)
+			if err != nil {
+				return &QueryParamError{Key: key, Err: err}
+			}
+
+			values.Add(key, s)
+
+			continue
+		}
+
+		for i := 0; i < rv.Len(); i++ {
+			elem := rv.Index(i)
+			if elem.Kind() == reflect.Interface {
+				if elem.IsNil() {
+					continue
+				}
+				elem = elem.Elem()
+			}
+
+			s, err := stringifyQueryValue(elem)
+			if err != nil {
+				return &QueryParamError{Key: key, Err: err}
+			}
+
+			values.Add(key, s)
+		}
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	u.deferValues(values)
+
+	return nil
+}
+
+func stringifyQueryValue(rv reflect.Value) (string, error) {
+	if t, ok := rv.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339), nil
+	}
+
+	switch kind := rv.Kind(); {
+	case kind == reflect.String:
+		return rv.String(), nil
+	case kind == reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case rv.CanInt():
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case rv.CanUint() && kind != reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case rv.CanFloat():
+		return strconv.FormatFloat(rv.Float(), 'f', -1, rv.Type().Bits()), nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", rv.Type())
+	}
+}
+
 func (u *urlBuilder) build(base string) string {
 	var url strings.Builder
 
@@ -73,16 +470,15 @@ func (u *urlBuilder) build(base string) string {
 		url.WriteString(p)
 	}
 
-	if len(u.queries) == 0 {
-		return url.String()
-	}
-
-	url.WriteRune('?')
-	url.WriteString(u.queries[0])
+	separator := '?'
+	for _, q := range u.queries {
+		if q == "" {
+			continue // e.g., all the values are skipped by WithQuerySkipEmpty
+		}
 
-	for _, q := range u.queries[1:] {
-		url.WriteRune('&')
+		url.WriteRune(separator)
 		url.WriteString(q)
+		separator = '&'
 	}
 
 	return url.String()
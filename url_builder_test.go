@@ -4,7 +4,11 @@
 package rqx
 
 import (
+	"fmt"
+	"net/http"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -128,6 +132,26 @@ func Test_urlBuilder(t *testing.T) {
 			},
 			want: "https://www.example.com?first=1&second%5B%5D=2&second%5B%5D=3&second%5B%5D=4&second%5B%5D=5",
 		},
+		{
+			name: "URL with indexed query",
+			urlFunc: func() (string, error) {
+				data := struct {
+					First  string   `url:"first"`
+					Second []string `url:"second,brackets"`
+				}{
+					First:  "1",
+					Second: []string{"2", "3", "4", "5"},
+				}
+
+				u := &urlBuilder{}
+				u.deferQuery(&data, true)
+				if err := u.resolveQueries(); err != nil {
+					return "", err
+				}
+				return u.build("https://www.example.com"), nil
+			},
+			want: "https://www.example.com?first=1&second%5B0%5D=2&second%5B1%5D=3&second%5B2%5D=4&second%5B3%5D=5",
+		},
 		{
 			name: "URL with error query",
 			urlFunc: func() (string, error) {
@@ -139,6 +163,40 @@ func Test_urlBuilder(t *testing.T) {
 			},
 			hasError: true,
 		},
+		{
+			name: "URL with query from map",
+			urlFunc: func() (string, error) {
+				u := &urlBuilder{}
+				err := u.appendQueryFromMap(map[string]any{
+					"int":   42,
+					"bool":  true,
+					"float": 1.5,
+					"time":  time.Date(2025, time.January, 2, 3, 4, 5, 0, time.UTC),
+					"nil":   nil,
+					"slice": []any{"a b", nil, uint8(7)},
+				})
+				if err != nil {
+					return "", err
+				}
+				if err := u.resolveQueries(); err != nil {
+					return "", err
+				}
+				return u.build("https://www.example.com"), nil
+			},
+			want: "https://www.example.com?bool=true&float=1.5&int=42&slice=a+b&slice=7&time=2025-01-02T03%3A04%3A05Z",
+		},
+		{
+			name: "URL with unsupported query from map",
+			urlFunc: func() (string, error) {
+				u := &urlBuilder{}
+				err := u.appendQueryFromMap(map[string]any{"map": map[string]int{}})
+				if err != nil {
+					return "", err
+				}
+				return u.build("https://www.example.com"), nil
+			},
+			hasError: true,
+		},
 		{
 			name: "URL with paths and query",
 			urlFunc: func() (string, error) {
@@ -196,3 +254,289 @@ func Test_FromUint(t *testing.T) {
 	assert.Equal(t, "42", FromUint(uint32(42)))
 	assert.Equal(t, "42", FromUint(uint64(42)))
 }
+
+func Test_urlBuilder_checkDuplicateQueryKeys(t *testing.T) {
+	t.Parallel()
+
+	type common struct {
+		Page  int      `url:"page"`
+		Limit int      `url:"limit"`
+		Tags  []string `url:"tag"`
+	}
+
+	type endpoint struct {
+		Page int    `url:"page"`
+		Sort string `url:"sort"`
+	}
+
+	u := &urlBuilder{}
+	require.NoError(t, u.appendQuery(common{Page: 1, Limit: 10, Tags: []string{"a", "b"}}))
+	require.NoError(t, u.checkDuplicateQueryKeys())
+
+	require.NoError(t, u.appendQuery(endpoint{Page: 2, Sort: "name"}))
+	require.NoError(t, u.appendQueryFromMap(map[string]any{"limit": 20}))
+	require.NoError(t, u.resolveQueries())
+
+	err := u.checkDuplicateQueryKeys()
+	require.ErrorIs(t, err, ErrDuplicateQueryKeys)
+	assert.Contains(t, err.Error(), "limit, page")
+
+	u.allowDuplicateQueryKeys = true
+	require.NoError(t, u.checkDuplicateQueryKeys())
+}
+
+type money struct {
+	units    int64
+	currency string
+}
+
+func Test_WithQueryEncoder(t *testing.T) {
+	t.Parallel()
+
+	type filter struct {
+		Min money
+		Max money
+	}
+
+	encoder := func(data any) (url.Values, error) {
+		f, ok := data.(filter)
+		if !ok {
+			return nil, fmt.Errorf("unsupported query type %T", data)
+		}
+
+		format := func(m money) string { return fmt.Sprintf("%d%s", m.units, m.currency) }
+
+		return url.Values{
+			"min": {format(f.Min)},
+			"max": {format(f.Max)},
+		}, nil
+	}
+
+	params, err := newDoParams(
+		WithQueryFromMap(map[string]any{"page": 1}),
+		WithQuery(filter{Min: money{10, "USD"}, Max: money{20, "USD"}}),
+		WithQueryFromMap(map[string]any{"limit": 5}),
+		WithQueryEncoder(encoder),
+	)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"https://www.example.com?page=1&max=20USD&min=10USD&limit=5",
+		params.urlBuilder.build("https://www.example.com"),
+	)
+
+	_, err = newDoParams(WithQueryEncoder(encoder), WithQuery(struct{}{}))
+	require.Error(t, err)
+}
+
+func Test_WithQuerySkipEmpty(t *testing.T) {
+	t.Parallel()
+
+	type filter struct {
+		Query string   `url:"q"`
+		Page  int      `url:"page"`
+		Tags  []string `url:"tags,brackets"`
+	}
+
+	data := filter{Page: 1, Tags: []string{"", "go"}}
+
+	got, err := BuildURL("https://example.com", WithQuery(data))
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com?page=1&q=&tags%5B%5D=&tags%5B%5D=go", got)
+
+	got, err = BuildURL("https://example.com", WithQueryIndexed(data), WithQuerySkipEmpty())
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com?page=1&tags%5B0%5D=go", got)
+
+	empty := struct {
+		Query string `url:"q"`
+	}{}
+
+	got, err = BuildURL("https://example.com", WithQuery(empty), WithQuerySkipEmpty())
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", got)
+
+	got, err = BuildURL("https://example.com",
+		WithQuery(empty),
+		WithQueryFromMap(map[string]any{"page": 1, "sort": ""}),
+		WithQuerySkipEmpty(),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com?page=1", got)
+
+	got, err = BuildURL("https://example.com",
+		WithQuerySkipEmpty(),
+		WithQueryFromMap(map[string]any{"sort": "", "tags": []string{"", "go"}}),
+		WithQuery(empty),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com?tags=go", got)
+}
+
+func Test_WithQueryArray(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "comma",
+			opts: []Option{WithQueryArray("ids", []string{"1", "2", "3"}, ",")},
+			want: "https://example.com?ids=1,2,3",
+		},
+		{
+			name: "space",
+			opts: []Option{WithQueryArray("q", []string{"a", "b"}, " ")},
+			want: "https://example.com?q=a%20b",
+		},
+		{
+			name: "escaped values",
+			opts: []Option{WithQueryArray("tag[]", []string{"a,b", "c&d"}, "|")},
+			want: "https://example.com?tag%5B%5D=a%2Cb|c%26d",
+		},
+		{
+			name: "no values",
+			opts: []Option{WithQueryArray("ids", nil, ",")},
+			want: "https://example.com",
+		},
+		{
+			name: "with other queries",
+			opts: []Option{
+				WithQueryFromMap(map[string]any{"page": 1}),
+				WithQueryArray("fields", []string{"id", "name"}, ","),
+			},
+			want: "https://example.com?page=1&fields=id,name",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := BuildURL("https://example.com", tt.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := BuildURL("https://example.com",
+		WithQueryArray("ids", []string{"1"}, ","),
+		WithQueryArray("ids", []string{"2"}, ","),
+	)
+	require.ErrorIs(t, err, ErrDuplicateQueryKeys)
+}
+
+func Test_WithQueryArrayFormat(t *testing.T) {
+	t.Parallel()
+
+	type filter struct {
+		IDs  []int    `url:"ids"`
+		Tags []string `url:"tag,brackets"`
+		Page int      `url:"page"`
+	}
+
+	opts := []Option{
+		WithQuery(filter{IDs: []int{1, 2}, Tags: []string{"a,b"}, Page: 3}),
+		WithQueryFromMap(map[string]any{"sort": []string{"name", "date"}}),
+		WithQueryArray("fields", []string{"id", "name"}, ","),
+	}
+
+	tests := []struct {
+		name   string
+		format ArrayFormat
+		want   string
+	}{
+		{
+			name: "unset",
+			want: "ids=1&ids=2&page=3&tag%5B%5D=a%2Cb&sort=name&sort=date&fields=id,name",
+		},
+		{
+			name:   "repeat",
+			format: ArrayFormatRepeat,
+			want:   "ids=1&ids=2&page=3&tag=a%2Cb&sort=name&sort=date&fields=id,name",
+		},
+		{
+			name:   "brackets",
+			format: ArrayFormatBrackets,
+			want: "ids%5B%5D=1&ids%5B%5D=2&page=3&tag%5B%5D=a%2Cb" +
+				"&sort%5B%5D=name&sort%5B%5D=date&fields=id,name",
+		},
+		{
+			name:   "comma",
+			format: ArrayFormatComma,
+			want:   "ids=1,2&page=3&tag=a%2Cb&sort=name,date&fields=id,name",
+		},
+		{
+			name:   "index",
+			format: ArrayFormatIndex,
+			want: "ids%5B0%5D=1&ids%5B1%5D=2&page=3&tag%5B0%5D=a%2Cb" +
+				"&sort%5B0%5D=name&sort%5B1%5D=date&fields=id,name",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			all := opts
+			if tt.format != 0 {
+				// The format applies regardless of the order of the options.
+				all = append([]Option{WithQueryArrayFormat(tt.format)}, opts...)
+			}
+
+			got, err := BuildURL("https://example.com", all...)
+			require.NoError(t, err)
+			assert.Equal(t, "https://example.com?"+tt.want, got)
+		})
+	}
+
+	_, err := newDoParams(WithQueryArrayFormat(ArrayFormat(42)))
+	require.Error(t, err)
+}
+
+func Test_BuildURL(t *testing.T) {
+	t.Parallel()
+
+	opts := []Option{
+		WithURLPaths("users", "42"),
+		WithQuery(struct {
+			Page int `url:"page"`
+		}{Page: 2}),
+		WithQueryFromMap(map[string]any{"sort": "name"}),
+		WithJSON(map[string]int{"a": 1}), // the body does not affect the URL
+	}
+
+	got, err := BuildURL("https://example.com/api", opts...)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/api/users/42?page=2&sort=name", got)
+
+	var final string
+	err = Get("https://example.com/api", append(opts[:3:3],
+		WithFinalURL(&final),
+		WithClient(&http.Client{Transport: roundTripperFunc(
+			func(*http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			},
+		)}),
+		WithExpectStatus(http.StatusOK),
+	)...)
+	require.NoError(t, err)
+	assert.Equal(t, got, final)
+
+	_, err = BuildURL("https://example.com", WithQueryFromMap(map[string]any{"bad": struct{}{}}))
+	var paramErr *QueryParamError
+	require.ErrorAs(t, err, &paramErr)
+	assert.Equal(t, "bad", paramErr.Key)
+
+	_, err = BuildURL("ftp://example.com")
+	var urlErr *InvalidURLError
+	require.ErrorAs(t, err, &urlErr)
+	assert.Equal(t, URLPartScheme, urlErr.Part)
+
+	// The options fail as they would in Do.
+	_, err = BuildURL("https://example.com", append(opts[:4:4], WithBytes(nil))...)
+	require.ErrorIs(t, err, ErrBodyAlreadyExists)
+}
@@ -0,0 +1,91 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"errors"
+	"fmt"
+	urlpkg "net/url"
+	"strings"
+)
+
+var defaultAllowedSchemes = []string{"http", "https"}
+
+type urlValidator struct {
+	allowedSchemes []string
+	checkBase      bool
+	maxLength      int
+}
+
+func (v *urlValidator) validateBase(base string) error {
+	if !v.checkBase {
+		return nil
+	}
+
+	if err := v.validate(base); err != nil {
+		err.IsBase = true
+		return err
+	}
+
+	return nil
+}
+
+func (v *urlValidator) validateURL(url string) error {
+	if v.maxLength > 0 && len(url) > v.maxLength {
+		return &InvalidURLError{
+			URL:  url,
+			Part: URLPartLength,
+			Err:  fmt.Errorf("%d bytes exceed the limit of %d bytes", len(url), v.maxLength),
+		}
+	}
+
+	if err := v.validate(url); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (v *urlValidator) validate(url string) *InvalidURLError {
+	for i := 0; i < len(url); i++ {
+		if c := url[i]; c < 0x20 || c == 0x7f {
+			return &InvalidURLError{
+				URL:  url,
+				Part: URLPartControl,
+				Err:  fmt.Errorf("byte %#x at position %d", c, i),
+			}
+		}
+	}
+
+	parsed, err := urlpkg.Parse(url)
+	if err != nil {
+		return &InvalidURLError{URL: url, Part: URLPartSyntax, Err: err}
+	}
+
+	if !v.isAllowedScheme(parsed.Scheme) {
+		return &InvalidURLError{
+			URL:  url,
+			Part: URLPartScheme,
+			Err: fmt.Errorf("scheme %q is not one of %s",
+				parsed.Scheme, strings.Join(v.allowedSchemes, ", "),
+			),
+		}
+	}
+
+	if parsed.Host == "" {
+		return &InvalidURLError{URL: url, Part: URLPartHost, Err: errors.New("host is empty")}
+	}
+
+	return nil
+}
+
+func (v *urlValidator) isAllowedScheme(scheme string) bool {
+	for _, allowed := range v.allowedSchemes {
+		if strings.EqualFold(scheme, allowed) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,90 @@
+// This file is licensed under the terms of the MIT License (see LICENSE file)
+// Copyright (c) 2025 Pavel Tsayukov p.tsayukov@gmail.com
+
+package rqx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_urlValidator(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		url     string
+		schemes []string
+		part    URLPart
+	}{
+		{
+			name: "Valid URL",
+			url:  "https://www.example.com/one?first=1",
+		},
+		{
+			name: "Upper-case scheme",
+			url:  "HTTP://www.example.com",
+		},
+		{
+			name: "Empty URL",
+			url:  "",
+			part: URLPartScheme,
+		},
+		{
+			name: "Missing scheme",
+			url:  "www.example.com/one",
+			part: URLPartScheme,
+		},
+		{
+			name: "Disallowed scheme",
+			url:  "ftp://www.example.com",
+			part: URLPartScheme,
+		},
+		{
+			name:    "Custom scheme",
+			url:     "ftp://www.example.com",
+			schemes: []string{"ftp"},
+		},
+		{
+			name: "Empty host",
+			url:  "https:///one",
+			part: URLPartHost,
+		},
+		{
+			name: "Control character",
+			url:  "https://www.example.com/one\n",
+			part: URLPartControl,
+		},
+		{
+			name: "Bad syntax",
+			url:  "https://www.example.com:port",
+			part: URLPartSyntax,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			v := urlValidator{allowedSchemes: defaultAllowedSchemes}
+			if tt.schemes != nil {
+				v.allowedSchemes = tt.schemes
+			}
+
+			err := v.validateURL(tt.url)
+
+			if tt.part == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var urlErr *InvalidURLError
+			require.ErrorAs(t, err, &urlErr)
+			assert.Equal(t, tt.part, urlErr.Part)
+			assert.False(t, urlErr.IsBase)
+		})
+	}
+}